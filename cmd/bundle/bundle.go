@@ -0,0 +1,121 @@
+package bundle
+
+import (
+	"context"
+	"fmt"
+
+	grip "github.com/alexjoedt/grip/internal"
+	"github.com/urfave/cli/v2"
+)
+
+func Command(ctx context.Context, app *cli.App, scopes *grip.Scopes) {
+	exportCmd := &cli.Command{
+		Name:      "export",
+		Usage:     "packages releases into a bundle for offline installation",
+		ArgsUsage: "<name|repo>...",
+		Flags: []cli.Flag{
+			&cli.StringFlag{
+				Name:     "output",
+				Aliases:  []string{"o"},
+				Usage:    "path of the bundle file",
+				Required: true,
+			},
+			&cli.StringSliceFlag{
+				Name:    "platform",
+				Aliases: []string{"p"},
+				Usage:   "target platform as os/arch, can be repeated (default: current platform)",
+			},
+			&cli.StringFlag{
+				Name:  "os",
+				Usage: "target operating system, combined with the host's arch unless --arch is set",
+			},
+			&cli.StringFlag{
+				Name:  "arch",
+				Usage: "target architecture, combined with the host's OS unless --os is set",
+			},
+			&cli.BoolFlag{
+				Name:  "system",
+				Usage: "exports installed names from the system wide registry",
+			},
+		},
+		Action: func(c *cli.Context) error {
+			if c.NArg() == 0 {
+				return fmt.Errorf("please provide the names or repos to export")
+			}
+
+			var platforms []grip.Platform
+			for _, value := range c.StringSlice("platform") {
+				p, err := grip.ParsePlatform(value)
+				if err != nil {
+					return err
+				}
+				platforms = append(platforms, p)
+			}
+
+			installer, err := scopes.Installer(grip.ScopeOf(c.Bool("system")))
+			if err != nil {
+				return err
+			}
+			if c.String("os") != "" || c.String("arch") != "" {
+				platforms = append(platforms, installer.Config().Platform().Override(c.String("os"), c.String("arch")))
+			}
+
+			return installer.ExportBundle(ctx, c.Args().Slice(), platforms, c.String("output"))
+		},
+	}
+
+	importCmd := &cli.Command{
+		Name:      "import",
+		Usage:     "installs all packages of a bundle without network access",
+		ArgsUsage: "<bundle>",
+		Flags: []cli.Flag{
+			&cli.BoolFlag{
+				Name:    "force",
+				Aliases: []string{"f"},
+				Usage:   "replaces already installed packages",
+			},
+			&cli.StringFlag{
+				Name:    "destination",
+				Aliases: []string{"d"},
+				Usage:   "absolute directory to install the executables to (default: the grip bin dir)",
+			},
+			&cli.StringFlag{
+				Name:  "os",
+				Usage: "installs the packages for another operating system into --destination",
+			},
+			&cli.StringFlag{
+				Name:  "arch",
+				Usage: "installs the packages for another architecture into --destination",
+			},
+			&cli.BoolFlag{
+				Name:  "system",
+				Usage: "installs system wide, requires root",
+			},
+		},
+		Action: func(c *cli.Context) error {
+			path := c.Args().First()
+			if path == "" {
+				return fmt.Errorf("please provide the path of the bundle")
+			}
+
+			installer, err := scopes.Installer(grip.ScopeOf(c.Bool("system")))
+			if err != nil {
+				return err
+			}
+
+			return installer.ImportBundle(path, grip.ImportOptions{
+				Force:       c.Bool("force"),
+				Destination: c.String("destination"),
+				OS:          c.String("os"),
+				Arch:        c.String("arch"),
+			})
+		},
+	}
+
+	cmd := &cli.Command{
+		Name:        "bundle",
+		Usage:       "exports and imports offline bundles",
+		Subcommands: []*cli.Command{exportCmd, importCmd},
+	}
+	app.Commands = append(app.Commands, cmd)
+}
@@ -0,0 +1,166 @@
+package config
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"strings"
+
+	grip "github.com/alexjoedt/grip/internal"
+	"github.com/alexjoedt/grip/internal/logger"
+	"github.com/urfave/cli/v2"
+)
+
+func Command(app *cli.App) {
+	getCmd := &cli.Command{
+		Name:      "get",
+		Usage:     "prints the value of a key",
+		ArgsUsage: "<key>",
+		Action: func(c *cli.Context) error {
+			key := c.Args().First()
+			if key == "" {
+				return fmt.Errorf("please provide the key, valid: %s", keyList())
+			}
+
+			_, fc, err := readConfig()
+			if err != nil {
+				return err
+			}
+
+			value, err := fc.Get(key)
+			if err != nil {
+				return err
+			}
+			logger.Println("%s", value)
+			return nil
+		},
+	}
+
+	setCmd := &cli.Command{
+		Name:      "set",
+		Usage:     "sets the value of a key, lists are comma separated and an empty value removes the key",
+		ArgsUsage: "<key> <value>",
+		Action: func(c *cli.Context) error {
+			if c.NArg() != 2 {
+				return fmt.Errorf("please provide the key and the value, valid keys: %s", keyList())
+			}
+
+			path, fc, err := readConfig()
+			if err != nil {
+				return err
+			}
+
+			if err := fc.Set(c.Args().Get(0), c.Args().Get(1)); err != nil {
+				if cerr, ok := err.(*grip.ConfigError); ok {
+					cerr.Path = path
+				}
+				return err
+			}
+			return grip.WriteConfigFile(path, fc)
+		},
+	}
+
+	listCmd := &cli.Command{
+		Name:  "list",
+		Usage: "lists all keys set in the config file",
+		Action: func(c *cli.Context) error {
+			path, fc, err := readConfig()
+			if err != nil {
+				return err
+			}
+
+			logger.Info("Config file: %s", path)
+			for _, key := range fc.Keys() {
+				value, _ := fc.Get(key)
+				if key == "token" {
+					value = "********"
+				}
+				logger.Println("%s = %s", key, value)
+			}
+			return nil
+		},
+	}
+
+	editCmd := &cli.Command{
+		Name:  "edit",
+		Usage: "opens the config file in $VISUAL or $EDITOR",
+		Action: func(c *cli.Context) error {
+			path, err := grip.ConfigFilePath()
+			if err != nil {
+				return err
+			}
+
+			if _, err := os.Stat(path); os.IsNotExist(err) {
+				if err := grip.WriteConfigFile(path, &grip.FileConfig{}); err != nil {
+					return err
+				}
+			}
+
+			editor := os.Getenv("VISUAL")
+			if editor == "" {
+				editor = os.Getenv("EDITOR")
+			}
+			if editor == "" {
+				editor = "vi"
+			}
+
+			// The editor may come with arguments, e.g. "code --wait"
+			parts := strings.Fields(editor)
+			cmd := exec.Command(parts[0], append(parts[1:], path)...)
+			cmd.Stdin = os.Stdin
+			cmd.Stdout = os.Stdout
+			cmd.Stderr = os.Stderr
+			if err := cmd.Run(); err != nil {
+				return fmt.Errorf("run editor: %w", err)
+			}
+
+			_, err = grip.ReadConfigFile(path)
+			return err
+		},
+	}
+
+	pathCmd := &cli.Command{
+		Name:  "path",
+		Usage: "prints the path of the config file",
+		Action: func(c *cli.Context) error {
+			path, err := grip.ConfigFilePath()
+			if err != nil {
+				return err
+			}
+			logger.Println("%s", path)
+			return nil
+		},
+	}
+
+	cmd := &cli.Command{
+		Name:        "config",
+		Usage:       "manages the grip config file",
+		Description: "manages the grip config file, located at $GRIP_CONFIG or $XDG_CONFIG_HOME/grip/config.toml",
+		Subcommands: []*cli.Command{getCmd, setCmd, listCmd, editCmd, pathCmd},
+	}
+	app.Commands = append(app.Commands, cmd)
+}
+
+func readConfig() (string, *grip.FileConfig, error) {
+	path, err := grip.ConfigFilePath()
+	if err != nil {
+		return "", nil, err
+	}
+
+	fc, err := grip.ReadConfigFile(path)
+	if err != nil {
+		return "", nil, err
+	}
+	return path, fc, nil
+}
+
+func keyList() string {
+	keys := make([]string, len(grip.ConfigKeys))
+	for i, key := range grip.ConfigKeys {
+		if strings.HasSuffix(key, ".") {
+			key += "<name>"
+		}
+		keys[i] = key
+	}
+	return strings.Join(keys, ", ")
+}
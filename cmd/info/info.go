@@ -0,0 +1,128 @@
+package info
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"strings"
+	"text/tabwriter"
+
+	grip "github.com/alexjoedt/grip/internal"
+	"github.com/urfave/cli/v2"
+)
+
+func Command(ctx context.Context, app *cli.App, scopes *grip.Scopes) {
+	cmd := &cli.Command{
+		Name:      "info",
+		Usage:     "shows the installation, repository and latest release of a package",
+		ArgsUsage: "<name|repo>",
+		Flags: []cli.Flag{
+			&cli.BoolFlag{
+				Name:  "json",
+				Usage: "prints the details as JSON",
+			},
+			&cli.BoolFlag{
+				Name:  "system",
+				Usage: "looks up system wide installations",
+			},
+		},
+		Action: func(c *cli.Context) error {
+			if c.NArg() == 0 {
+				return fmt.Errorf("please provide an installed name or a repo")
+			}
+
+			installer, err := scopes.Installer(grip.ScopeOf(c.Bool("system")))
+			if err != nil {
+				return err
+			}
+
+			info, err := installer.Info(ctx, c.Args().First())
+			if err != nil {
+				return err
+			}
+
+			if c.Bool("json") {
+				enc := json.NewEncoder(os.Stdout)
+				enc.SetIndent("", "  ")
+				return enc.Encode(info)
+			}
+			return printInfo(info)
+		},
+	}
+	app.Commands = append(app.Commands, cmd)
+}
+
+// printInfo prints the details as text
+func printInfo(info *grip.Info) error {
+	const timeFormat = "2006-01-02 15:04"
+
+	tw := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintf(tw, "Repo:\t%s\n", info.Repo)
+	if info.Description != "" {
+		fmt.Fprintf(tw, "Description:\t%s\n", info.Description)
+	}
+	if info.License != "" {
+		fmt.Fprintf(tw, "License:\t%s\n", info.License)
+	}
+
+	if inst := info.Installed; inst != nil {
+		fmt.Fprintf(tw, "Installed:\t%s %s\n", inst.Name, inst.Tag)
+		fmt.Fprintf(tw, "Install path:\t%s\n", inst.InstallPath)
+		if inst.SHA256 != "" {
+			fmt.Fprintf(tw, "SHA256:\t%s\n", inst.SHA256)
+		}
+		fmt.Fprintf(tw, "Installed at:\t%s\n", inst.InstalledAt.Local().Format(timeFormat))
+		fmt.Fprintf(tw, "Updated at:\t%s\n", inst.UpdatedAt.Local().Format(timeFormat))
+	} else {
+		fmt.Fprintf(tw, "Installed:\tno\n")
+	}
+
+	latest := info.Latest
+	if latest == nil {
+		return tw.Flush()
+	}
+	update := ""
+	if info.UpdateAvailable {
+		update = ", update available"
+	}
+	fmt.Fprintf(tw, "Latest release:\t%s (%s%s)\n", latest.Tag, latest.PublishedAt.Local().Format("2006-01-02"), update)
+	if latest.URL != "" {
+		fmt.Fprintf(tw, "\t%s\n", latest.URL)
+	}
+	if err := tw.Flush(); err != nil {
+		return err
+	}
+
+	if latest.Notes != "" {
+		fmt.Println("\nRelease notes:")
+		for _, line := range strings.Split(latest.Notes, "\n") {
+			fmt.Printf("  %s\n", line)
+		}
+	}
+
+	fmt.Printf("\nAssets for %s", latest.Platform)
+	if latest.Pattern != "" {
+		fmt.Printf(" with asset pattern %q", latest.Pattern)
+	}
+	fmt.Println(":")
+	tw = tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintf(tw, "  \tSCORE\tASSET\tREASONS\n")
+	for _, a := range latest.Assets {
+		mark := ""
+		if a.Name == latest.Selected {
+			mark = "*"
+		}
+		fmt.Fprintf(tw, "  %s\t%d\t%s\t%s\n", mark, a.Score, a.Name, strings.Join(a.Reasons, ", "))
+	}
+	if err := tw.Flush(); err != nil {
+		return err
+	}
+
+	if latest.Selected != "" {
+		fmt.Printf("\n* installed by grip on %s\n", latest.Platform)
+	} else {
+		fmt.Printf("\nNo asset is installed on %s: %s\n", latest.Platform, latest.SelectError)
+	}
+	return nil
+}
@@ -2,12 +2,14 @@ package install
 
 import (
 	"context"
+	"os"
 
 	grip "github.com/alexjoedt/grip/internal"
 	"github.com/urfave/cli/v2"
+	"golang.org/x/term"
 )
 
-func Command(ctx context.Context, app *cli.App, installer *grip.Installer) {
+func Command(ctx context.Context, app *cli.App, scopes *grip.Scopes) {
 	cmd := &cli.Command{
 		Name:  "install",
 		Usage: "install an executable from a GitHub release",
@@ -27,13 +29,70 @@ func Command(ctx context.Context, app *cli.App, installer *grip.Installer) {
 				Aliases: []string{"a"},
 				Usage:   "alias for the executable",
 			},
+			&cli.StringFlag{
+				Name:    "destination",
+				Aliases: []string{"d"},
+				Usage:   "absolute directory to install the executable to (default: the grip bin dir)",
+			},
+			&cli.StringFlag{
+				Name:  "asset-pattern",
+				Usage: "glob selecting the release asset, or a regex prefixed with re: (placeholders: {{os}}, {{arch}}, {{version}})",
+			},
+			&cli.StringFlag{
+				Name:  "binary",
+				Usage: "file inside the archive to install, e.g. bin/tool",
+			},
+			&cli.StringSliceFlag{
+				Name:  "bin",
+				Usage: "executables inside the archive to install under their own names, e.g. --bin kubectl,kubeadm",
+			},
+			&cli.BoolFlag{
+				Name:  "all-bins",
+				Usage: "installs every executable inside the archive",
+			},
+			&cli.BoolFlag{
+				Name:  "scripts",
+				Usage: "considers #! scripts inside the archive as executables",
+			},
+			&cli.BoolFlag{
+				Name:  "skip-arch-check",
+				Usage: "installs the binary even if it's built for another OS or architecture",
+			},
+			&cli.StringFlag{
+				Name:  "os",
+				Usage: "installs for another operating system into --destination, e.g. windows",
+			},
+			&cli.StringFlag{
+				Name:  "arch",
+				Usage: "installs for another architecture into --destination, e.g. arm64",
+			},
+			&cli.BoolFlag{
+				Name:  "system",
+				Usage: "installs system wide into the system prefix, requires root",
+			},
 		},
 		Action: func(c *cli.Context) error {
+			installer, err := scopes.Installer(grip.ScopeOf(c.Bool("system")))
+			if err != nil {
+				return err
+			}
+
 			opts := grip.InstallOptions{
-				Repo:  c.Args().First(),
-				Tag:   c.String("tag"),
-				Force: c.Bool("force"),
-				Alias: c.String("alias"),
+				Repo:        c.Args().First(),
+				Tag:         c.String("tag"),
+				Force:       c.Bool("force"),
+				Alias:       c.String("alias"),
+				Destination: c.String("destination"),
+
+				AssetPattern:  c.String("asset-pattern"),
+				Binary:        c.String("binary"),
+				Interactive:   term.IsTerminal(int(os.Stdin.Fd())),
+				SkipArchCheck: c.Bool("skip-arch-check"),
+				Bins:          c.StringSlice("bin"),
+				AllBins:       c.Bool("all-bins"),
+				Scripts:       c.Bool("scripts"),
+				OS:            c.String("os"),
+				Arch:          c.String("arch"),
 			}
 
 			return installer.Install(ctx, opts)
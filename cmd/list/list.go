@@ -12,7 +12,7 @@ import (
 	"github.com/urfave/cli/v2"
 )
 
-func Command(app *cli.App, storage *grip.Storage) {
+func Command(app *cli.App, scopes *grip.Scopes) {
 	cmd := &cli.Command{
 		Name:  "ls",
 		Usage: "lists all installed executables by grip",
@@ -21,9 +21,18 @@ func Command(app *cli.App, storage *grip.Storage) {
 				Name:  "filter",
 				Usage: "filters installed executables (format: field=regex)",
 			},
+			&cli.BoolFlag{
+				Name:  "system",
+				Usage: "lists system wide installations",
+			},
 		},
 		Action: func(c *cli.Context) error {
-			installations, err := storage.List()
+			installer, err := scopes.Installer(grip.ScopeOf(c.Bool("system")))
+			if err != nil {
+				return err
+			}
+
+			installations, err := installer.Storage().List()
 			if err != nil {
 				return err
 			}
@@ -65,10 +74,25 @@ func Command(app *cli.App, storage *grip.Storage) {
 			tw := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
 			fmt.Fprintf(tw, "NAME\tTAG\tREPO\tINSTALL PATH\n")
 
+			// Mark installations outside the default bin dir
+			binDir := installer.Config().BinDir
+			custom := false
 			for _, inst := range installations {
-				fmt.Fprintf(tw, "%s\t%s\t%s\t%s\n", inst.Name, inst.Tag, inst.Repo, inst.InstallPath)
+				path := inst.InstallPath
+				if path != "" && path != binDir {
+					path += " *"
+					custom = true
+				}
+				fmt.Fprintf(tw, "%s\t%s\t%s\t%s\n", inst.Name, inst.Tag, inst.Repo, path)
+			}
+			if err := tw.Flush(); err != nil {
+				return err
+			}
+
+			if custom {
+				fmt.Printf("\n* installed outside the default bin dir %s\n", binDir)
 			}
-			return tw.Flush()
+			return nil
 		},
 	}
 	app.Commands = append(app.Commands, cmd)
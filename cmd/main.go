@@ -2,15 +2,19 @@ package main
 
 import (
 	"context"
-	"net/http"
+	"errors"
 	"os"
 	"os/signal"
+	"strings"
 	"syscall"
-	"time"
 
+	"github.com/alexjoedt/grip/cmd/bundle"
+	"github.com/alexjoedt/grip/cmd/config"
+	"github.com/alexjoedt/grip/cmd/info"
 	"github.com/alexjoedt/grip/cmd/install"
 	"github.com/alexjoedt/grip/cmd/list"
 	"github.com/alexjoedt/grip/cmd/remove"
+	"github.com/alexjoedt/grip/cmd/search"
 	"github.com/alexjoedt/grip/cmd/update"
 	grip "github.com/alexjoedt/grip/internal"
 	"github.com/alexjoedt/grip/internal/logger"
@@ -24,39 +28,34 @@ var (
 )
 
 func main() {
-	// Create config
-	cfg, err := grip.DefaultConfig()
+	// Create config from defaults, config file and environment
+	cfg, err := grip.LoadConfig()
 	if err != nil {
-		logger.Fatal("Failed to load config: %v", err)
+		// A broken config file must not prevent fixing it with the config command
+		var cerr *grip.ConfigError
+		if !errors.As(err, &cerr) || commandName() != "config" {
+			logger.Fatal("Failed to load config: %v", err)
+		}
+		logger.Warn("%v", err)
+		if cfg, err = grip.DefaultConfig(); err != nil {
+			logger.Fatal("Failed to load config: %v", err)
+		}
 	}
 
-	// Ensure directories exist
-	if err := cfg.EnsureDirs(); err != nil {
-		logger.Fatal("Failed to create directories: %v", err)
-	}
-
-	// Create storage
-	storage, err := grip.NewStorage(cfg.StorePath, cfg)
+	// Create HTTP client with proxy, CA and mirror settings
+	httpClient, err := grip.NewHTTPClient(cfg)
 	if err != nil {
-		logger.Fatal("Failed to initialize storage: %v", err)
+		logger.Fatal("Failed to create HTTP client: %v", err)
 	}
 
 	// Create GitHub client
-	ghClient := grip.NewGitHubClient()
-
-	// Create HTTP client optimized for downloading large binary files
-	httpClient := &http.Client{
-		Timeout: 2 * time.Minute, // Max timeout for large downloads
-		Transport: &http.Transport{
-			MaxIdleConns:        10,
-			MaxIdleConnsPerHost: 5,
-			IdleConnTimeout:     90 * time.Second,
-			DisableCompression:  true, // Don't decompress, we handle archives
-		},
+	ghClient, err := grip.NewGitHubClient(httpClient, cfg)
+	if err != nil {
+		logger.Fatal("Failed to create GitHub client: %v", err)
 	}
 
-	// Create installer
-	installer := grip.NewInstaller(cfg, storage, ghClient, httpClient)
+	// Installers and their directories are created when a command needs them
+	scopes := grip.NewScopes(cfg, ghClient, httpClient)
 
 	ctx, cancel := signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM)
 	defer cancel()
@@ -80,10 +79,14 @@ func main() {
 	}
 
 	versionCommand(app)
-	install.Command(ctx, app, installer)
-	update.Command(ctx, app, installer, storage, cfg)
-	list.Command(app, storage)
-	remove.Command(app, installer, storage)
+	install.Command(ctx, app, scopes)
+	update.Command(ctx, app, scopes)
+	list.Command(app, scopes)
+	info.Command(ctx, app, scopes)
+	search.Command(ctx, app, scopes)
+	remove.Command(app, scopes)
+	bundle.Command(ctx, app, scopes)
+	config.Command(app)
 
 	if err := app.Run(os.Args); err != nil {
 		logger.Error("%s", err.Error())
@@ -107,3 +110,13 @@ func versionCommand(app *cli.App) {
 	}
 	app.Commands = append(app.Commands, cmd)
 }
+
+// commandName returns the first argument that is not a flag
+func commandName() string {
+	for _, arg := range os.Args[1:] {
+		if !strings.HasPrefix(arg, "-") {
+			return arg
+		}
+	}
+	return ""
+}
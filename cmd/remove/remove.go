@@ -11,7 +11,7 @@ import (
 	"github.com/urfave/cli/v2"
 )
 
-func Command(app *cli.App, installer *grip.Installer, storage *grip.Storage) {
+func Command(app *cli.App, scopes *grip.Scopes) {
 	cmd := &cli.Command{
 		Name:        "remove",
 		Usage:       "removes an installed executable by grip",
@@ -27,8 +27,17 @@ func Command(app *cli.App, installer *grip.Installer, storage *grip.Storage) {
 				Aliases: []string{"f"},
 				Usage:   "forces remove without confirmation",
 			},
+			&cli.BoolFlag{
+				Name:  "system",
+				Usage: "removes system wide installations, requires root",
+			},
 		},
 		Action: func(c *cli.Context) error {
+			installer, err := scopes.Installer(grip.ScopeOf(c.Bool("system")))
+			if err != nil {
+				return err
+			}
+
 			if c.Bool("all") {
 				if !c.Bool("force") {
 					if !askForContinue() {
@@ -36,7 +45,7 @@ func Command(app *cli.App, installer *grip.Installer, storage *grip.Storage) {
 					}
 				}
 
-				installations, err := storage.List()
+				installations, err := installer.Storage().List()
 				if err != nil {
 					return err
 				}
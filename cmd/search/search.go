@@ -0,0 +1,106 @@
+package search
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"strings"
+	"text/tabwriter"
+
+	grip "github.com/alexjoedt/grip/internal"
+	"github.com/urfave/cli/v2"
+)
+
+// maxDescription is the length descriptions are truncated to
+const maxDescription = 60
+
+func Command(ctx context.Context, app *cli.App, scopes *grip.Scopes) {
+	cmd := &cli.Command{
+		Name:      "search",
+		Usage:     "searches GitHub for repositories with releases installable on this platform",
+		ArgsUsage: "<query>",
+		Flags: []cli.Flag{
+			&cli.IntFlag{
+				Name:    "limit",
+				Aliases: []string{"n"},
+				Usage:   "maximum number of repositories",
+				Value:   10,
+			},
+			&cli.BoolFlag{
+				Name:  "json",
+				Usage: "prints the results as JSON",
+			},
+		},
+		Action: func(c *cli.Context) error {
+			if c.NArg() == 0 {
+				return fmt.Errorf("please provide a search query")
+			}
+			if c.Int("limit") < 1 || c.Int("limit") > 100 {
+				return fmt.Errorf("--limit must be between 1 and 100")
+			}
+
+			installer, err := scopes.Installer(grip.ScopeUser)
+			if err != nil {
+				return err
+			}
+
+			results, err := installer.Search(ctx, strings.Join(c.Args().Slice(), " "), c.Int("limit"))
+			if err != nil {
+				return err
+			}
+
+			if c.Bool("json") {
+				enc := json.NewEncoder(os.Stdout)
+				enc.SetIndent("", "  ")
+				return enc.Encode(results)
+			}
+			if len(results) == 0 {
+				fmt.Println("No repositories found")
+				return nil
+			}
+			return printResults(results, installer.Config().Platform().String())
+		},
+	}
+	app.Commands = append(app.Commands, cmd)
+}
+
+// printResults prints the results as table, marking the installable ones
+// and those whose latest release is unknown
+func printResults(results []grip.SearchResult, platform string) error {
+	unknown := 0
+	tw := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(tw, "REPO\tSTARS\tLATEST\tDESCRIPTION")
+	for _, r := range results {
+		mark := ""
+		if r.Installable() {
+			mark = "*"
+		}
+		tag := r.Tag
+		switch {
+		case r.Error != "":
+			tag = "?"
+			unknown++
+		case tag == "":
+			tag = "-"
+		}
+		fmt.Fprintf(tw, "%s%s\t%d\t%s\t%s\n", r.Repo, mark, r.Stars, tag, truncate(r.Description, maxDescription))
+	}
+	if err := tw.Flush(); err != nil {
+		return err
+	}
+	fmt.Printf("\n* has a release asset for %s\n", platform)
+	if unknown > 0 {
+		fmt.Println("? latest release unknown, run with --verbose to see why")
+	}
+	return nil
+}
+
+// truncate shortens s to n runes
+func truncate(s string, n int) string {
+	r := []rune(s)
+	if len(r) <= n {
+		return s
+	}
+	return string(r[:n-3]) + "..."
+}
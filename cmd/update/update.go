@@ -9,16 +9,28 @@ import (
 	"github.com/urfave/cli/v2"
 )
 
-func Command(ctx context.Context, app *cli.App, installer *grip.Installer, storage *grip.Storage, cfg *grip.Config) {
+func Command(ctx context.Context, app *cli.App, scopes *grip.Scopes) {
 	cmd := &cli.Command{
 		Name:  "update",
 		Usage: "updates an executable",
+		Flags: []cli.Flag{
+			&cli.BoolFlag{
+				Name:  "system",
+				Usage: "updates a system wide installation, requires root",
+			},
+		},
 		Action: func(c *cli.Context) error {
 			name := c.Args().First()
 			if name == "" {
 				return fmt.Errorf("please provide the name of the package to update")
 			}
 
+			installer, err := scopes.Installer(grip.ScopeOf(c.Bool("system")))
+			if err != nil {
+				return err
+			}
+			storage := installer.Storage()
+
 			inst, err := storage.Get(name)
 			if err != nil {
 				return fmt.Errorf("package not found: %s", name)
@@ -44,6 +56,10 @@ func Command(ctx context.Context, app *cli.App, installer *grip.Installer, stora
 		Name:  "self-update",
 		Usage: "updates grip",
 		Action: func(c *cli.Context) error {
+			installer, err := scopes.Installer(grip.ScopeUser)
+			if err != nil {
+				return err
+			}
 			return grip.SelfUpdate(ctx, app.Version, installer)
 		},
 	}
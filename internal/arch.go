@@ -0,0 +1,88 @@
+package grip
+
+import (
+	"os/exec"
+	"runtime"
+	"runtime/debug"
+	"strconv"
+	"strings"
+)
+
+// defaultArchAliases returns the asset name tokens of each architecture, in
+// order of preference. The arm aliases depend on the ARM level of the host,
+// see armAliases.
+func defaultArchAliases(armLevel int) map[string][]string {
+	return map[string][]string{
+		"amd64":   {"x86_64", "x64", "win64"},
+		"arm64":   {"aarch64", "universal"},
+		"arm":     armAliases(armLevel),
+		"386":     {"i386", "i686", "i586", "x86", "ia32", "32bit"},
+		"riscv64": {"riscv64gc", "riscv"},
+		"ppc64le": {"powerpc64le", "ppc64el"},
+		"loong64": {"loongarch64"},
+	}
+}
+
+// armAliases returns the arm tokens acceptable on the ARM level, best first,
+// level 0 (not an arm host) is treated as armv6.
+// Older levels run on newer CPUs, so armv6 builds are the fallback on armv7.
+// The plain "arm" token carries no level and ranks below the exact one.
+// armhf is armv7 in Debian, so it is only accepted from armv7 on.
+func armAliases(level int) []string {
+	v7 := []string{"armv7", "armv7l", "armv7hf", "armhf", "arm7"}
+	v6 := []string{"armv6", "armv6l", "armv6hf", "arm6"}
+	v5 := []string{"armel", "armv5", "armv5l", "armv5te", "arm5"}
+
+	var aliases []string
+	switch {
+	case level >= 7:
+		aliases = append(append(append(aliases, v7...), "arm"), v6...)
+	case level == 6 || level == 0:
+		aliases = append(append(aliases, v6...), "arm")
+	default:
+		aliases = append(aliases, "arm")
+	}
+	return append(aliases, v5...)
+}
+
+// detectArmLevel returns the ARM level of the host, 0 if it isn't arm
+func detectArmLevel() int {
+	if runtime.GOARCH != "arm" {
+		return 0
+	}
+	machine, _ := exec.Command("uname", "-m").Output()
+	var goarm string
+	if info, ok := debug.ReadBuildInfo(); ok {
+		for _, s := range info.Settings {
+			if s.Key == "GOARM" {
+				goarm = s.Value
+			}
+		}
+	}
+	return detectArmLevelFrom(string(machine), goarm)
+}
+
+// detectArmLevelFrom parses the machine name of uname, e.g. "armv7l", and
+// falls back to the GOARM level grip was built with, e.g. "6,softfloat"
+func detectArmLevelFrom(machine, goarm string) int {
+	machine = strings.ToLower(strings.TrimSpace(machine))
+	if machine == "aarch64" || machine == "arm64" {
+		// 32-bit userland on a 64-bit CPU
+		return 7
+	}
+	if rest, ok := strings.CutPrefix(machine, "armv"); ok && rest != "" {
+		end := strings.IndexFunc(rest, func(r rune) bool { return r < '0' || r > '9' })
+		if end == -1 {
+			end = len(rest)
+		}
+		if level, err := strconv.Atoi(rest[:end]); err == nil {
+			return min(level, 7)
+		}
+	}
+
+	goarm, _, _ = strings.Cut(goarm, ",")
+	if level, err := strconv.Atoi(goarm); err == nil {
+		return level
+	}
+	return 6
+}
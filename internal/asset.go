@@ -1,6 +1,7 @@
 package grip
 
 import (
+	"errors"
 	"fmt"
 	"strings"
 
@@ -18,49 +19,162 @@ type Asset struct {
 	Tag         string
 	RepoName    string
 	RepoOwner   string
+	Pattern     string // asset pattern the asset was selected with, if any
+	Binary      string // file to install from the archive, if not detected
+
+	SkipArchCheck bool     // install the binary even if it's built for another platform
+	Bins          []string // further executables to install from the archive
+	AllBins       bool     // install every executable of the archive
+	Scripts       bool     // consider #! scripts as executables
 }
 
-// BinaryName returns the name for the installed binary
+// BinaryName returns the name for the installed binary, with the .exe
+// suffix for Windows
 func (a *Asset) BinaryName() string {
+	if a.OS == "windows" {
+		return withExeSuffix(a.baseName())
+	}
+	return a.baseName()
+}
+
+// baseName returns the name for the installed binary without suffix
+func (a *Asset) baseName() string {
 	if a.Alias != "" {
 		return a.Alias
 	}
+	if a.Binary != "" {
+		return binaryBase(a.Binary)
+	}
 	if a.RepoName != "" {
 		return a.RepoName
 	}
 	// Fallback: extract name from asset filename
 	name := strings.ToLower(a.Name)
-	for ext := range map[string]bool{
-		".tar.gz": true, ".tar.bz2": true, ".tbz": true,
-		".zip": true, ".tar.xz": true, ".bz2": true,
-	} {
-		name = strings.TrimSuffix(name, ext)
+	for _, ext := range orderedExts {
+		if trimmed, ok := strings.CutSuffix(name, ext); ok {
+			return trimmed
+		}
 	}
 	return name
 }
 
-// parseAsset selects the appropriate asset for the platform
-func parseAsset(assets []*github.ReleaseAsset, cfg *Config, repoOwner, repoName string) (*Asset, error) {
-	logger.Info("Parsing %d release assets for %s_%s", len(assets), cfg.OS, cfg.Arch)
-
-	for _, a := range assets {
-		name := strings.ToLower(*a.Name)
-		logger.Info("Evaluating asset: %s", name)
-
-		if MatchesPlatform(name, cfg.OS, cfg.Arch, cfg.OSAliases, cfg.ArchAliases) && IsSupportedFormat(name) {
-			logger.Info("Found compatible asset: %s", name)
-			return &Asset{
-				Name:        name,
-				OS:          cfg.OS,
-				Arch:        cfg.Arch,
-				DownloadURL: *a.BrowserDownloadURL,
-				RepoOwner:   repoOwner,
-				RepoName:    repoName,
-			}, nil
+// assetCandidates ranks the assets for the platform, see rankAssets. With a
+// match function, only the matching assets are returned, ranked at least 1.
+func assetCandidates(assets []*github.ReleaseAsset, cfg *Config, repoName string, match func(string) bool) []assetCandidate {
+	candidates := rankAssets(assets, cfg, repoName)
+	if match == nil {
+		return candidates
+	}
+	var matched []assetCandidate
+	for _, c := range candidates {
+		if match(c.name) && (IsSupportedFormat(c.name) || isRawBinary(c.name)) {
+			c.score = max(c.score, 1)
+			c.reasons = append(c.reasons, "matches pattern")
+			matched = append(matched, c)
 		}
 	}
+	return matched
+}
 
+// selectAssetQuietly returns the asset selectAsset selects without asking,
+// the first of equally ranked ones, but without logging anything
+func selectAssetQuietly(assets []*github.ReleaseAsset, cfg *Config, repoName string, match func(string) bool) (*github.ReleaseAsset, error) {
+	for _, c := range assetCandidates(assets, cfg, repoName, match) {
+		if c.score > 0 {
+			return c.asset, nil
+		}
+	}
+	if match != nil {
+		return nil, fmt.Errorf("no asset matches the asset pattern for %s_%s", cfg.OS, cfg.Arch)
+	}
 	return nil, fmt.Errorf("no asset found for %s_%s", cfg.OS, cfg.Arch)
 }
 
+// parseAsset selects the best ranked asset for the platform, see rankAssets
+func parseAsset(assets []*github.ReleaseAsset, cfg *Config, repoOwner, repoName string) (*Asset, error) {
+	return selectAsset(assets, cfg, repoOwner, repoName, "", nil, nil)
+}
+
+// selectAsset selects the best ranked asset for the platform. With a match
+// function, only matching assets are considered, regardless of their platform
+// tokens. With a pick function, the user chooses when several assets rank
+// equally or none matches, and the choice is remembered as asset pattern.
+// Otherwise the error lists the candidates.
+func selectAsset(assets []*github.ReleaseAsset, cfg *Config, repoOwner, repoName, tag string, match func(string) bool, pick pickFn) (*Asset, error) {
+	logger.Info("Ranking %d release assets for %s_%s", len(assets), cfg.OS, cfg.Arch)
+	if cfg.Libc != "" {
+		logger.Info("Assuming %s libc", cfg.Libc)
+	} else if cfg.OS == "linux" {
+		logger.Info("Unknown libc, not preferring gnu or musl builds")
+	}
+
+	candidates := assetCandidates(assets, cfg, repoName, match)
+	for _, c := range candidates {
+		logger.Info("%s", c)
+	}
 
+	var matching, installable []assetCandidate
+	for _, c := range candidates {
+		if c.score > 0 {
+			matching = append(matching, c)
+		}
+		if isInstallable(c.name) {
+			installable = append(installable, c)
+		}
+	}
+
+	var best assetCandidate
+	picked := false
+	switch {
+	case len(matching) == 0 && pick != nil && len(installable) > 0:
+		logger.Warn("No asset matches %s_%s", cfg.OS, cfg.Arch)
+		chosen, err := pick(installable)
+		if err != nil {
+			return nil, err
+		}
+		best, picked = chosen, true
+	case len(matching) == 0:
+		msg := fmt.Sprintf("no asset found for %s_%s", cfg.OS, cfg.Arch)
+		if match != nil {
+			msg = fmt.Sprintf("no asset matches the asset pattern for %s_%s", cfg.OS, cfg.Arch)
+			installable = nil
+			for _, a := range assets {
+				if name := strings.ToLower(a.GetName()); isInstallable(name) {
+					installable = append(installable, assetCandidate{asset: a, name: name})
+				}
+			}
+		}
+		if len(installable) > 0 {
+			return nil, fmt.Errorf("%s, candidates:%s\nuse --asset-pattern to select one", msg, candidateList(installable))
+		}
+		return nil, errors.New(msg)
+	case len(matching) > 1 && matching[1].score == matching[0].score && match == nil:
+		if pick == nil {
+			logger.Warn("Several assets match equally, using %s; use --asset-pattern to choose another:%s", matching[0].name, candidateList(matching))
+			best = matching[0]
+			break
+		}
+		chosen, err := pick(matching)
+		if err != nil {
+			return nil, err
+		}
+		best, picked = chosen, true
+	default:
+		best = matching[0]
+	}
+	logger.Info("Selected asset: %s", best.name)
+
+	asset := &Asset{
+		Name:        best.name,
+		OS:          cfg.OS,
+		Arch:        cfg.Arch,
+		DownloadURL: best.asset.GetBrowserDownloadURL(),
+		RepoOwner:   repoOwner,
+		RepoName:    repoName,
+	}
+	if picked {
+		asset.Pattern = derivePattern(best.name, cfg, tag)
+		logger.Info("Remembering asset pattern %q", asset.Pattern)
+	}
+	return asset, nil
+}
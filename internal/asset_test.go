@@ -6,7 +6,13 @@ import (
 	"bytes"
 	"compress/gzip"
 	"context"
+	"debug/elf"
+	"debug/macho"
+	"debug/pe"
+	"encoding/binary"
+	"errors"
 	"fmt"
+	"hash/crc32"
 	"io"
 	"net/http"
 	"os"
@@ -16,6 +22,7 @@ import (
 	"testing"
 
 	"github.com/google/go-github/v56/github"
+	"github.com/klauspost/compress/zstd"
 	"github.com/schollz/progressbar/v3"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/mock"
@@ -224,6 +231,22 @@ func TestUnpacker(t *testing.T) {
 		assert.True(t, IsSupportedFormat("test.tar.bz2"))
 		assert.False(t, IsSupportedFormat("test.txt"))
 		assert.False(t, IsSupportedFormat("test.exe"))
+		assert.True(t, IsSupportedFormat("test.tar"))
+		assert.True(t, IsSupportedFormat("test.tar.zst"))
+		assert.True(t, IsSupportedFormat("test.7z"))
+		assert.True(t, IsSupportedFormat("test.gz"))
+	})
+
+	t.Run("isRawBinary", func(t *testing.T) {
+		t.Parallel()
+
+		assert.True(t, isRawBinary("tool-linux-amd64"))
+		assert.True(t, isRawBinary("tool-1.2.0-linux-amd64"))
+		assert.True(t, isRawBinary("tool_1.2_linux_x86_64"))
+		assert.True(t, isRawBinary("tool-v1.0"))
+		assert.False(t, isRawBinary("tool.tar.gz"))
+		assert.True(t, isRawBinary("tool-windows-amd64.exe"))
+		assert.False(t, isRawBinary("tool-linux-amd64.sha256"))
 	})
 }
 
@@ -256,6 +279,18 @@ func TestBinaryInstaller(t *testing.T) {
 		assert.Equal(t, os.FileMode(0755), info.Mode().Perm())
 	})
 
+	t.Run("windows executable keeps its suffix", func(t *testing.T) {
+		t.Parallel()
+
+		srcPath := filepath.Join(t.TempDir(), "tool.exe")
+		require.NoError(t, os.WriteFile(srcPath, []byte("test binary content"), 0644))
+
+		binDir := filepath.Join(t.TempDir(), "bin")
+		require.NoError(t, InstallBinary(srcPath, binDir, "tool"))
+		assert.FileExists(t, filepath.Join(binDir, "tool.exe"))
+		assert.NoFileExists(t, filepath.Join(binDir, "tool"))
+	})
+
 	t.Run("invalid bin directory", func(t *testing.T) {
 		t.Parallel()
 
@@ -316,6 +351,7 @@ func TestAssetBinaryName(t *testing.T) {
 		alias     string
 		repoName  string
 		assetName string
+		os        string
 		expected  string
 	}{
 		{
@@ -337,6 +373,24 @@ func TestAssetBinaryName(t *testing.T) {
 			assetName: "tool_linux_amd64.tar.gz",
 			expected:  "tool_linux_amd64",
 		},
+		{
+			name:     "windows",
+			repoName: "tool",
+			os:       "windows",
+			expected: "tool.exe",
+		},
+		{
+			name:     "windows alias with suffix",
+			alias:    "tool.EXE",
+			os:       "windows",
+			expected: "tool.EXE",
+		},
+		{
+			name:      "windows raw binary",
+			assetName: "tool-windows-amd64.exe",
+			os:        "windows",
+			expected:  "tool-windows-amd64.exe",
+		},
 	}
 
 	for _, tc := range testCases {
@@ -347,6 +401,7 @@ func TestAssetBinaryName(t *testing.T) {
 				Alias:    tc.alias,
 				RepoName: tc.repoName,
 				Name:     tc.assetName,
+				OS:       tc.os,
 			}
 
 			result := asset.BinaryName()
@@ -467,6 +522,395 @@ func TestParseAsset(t *testing.T) {
 	}
 }
 
+// TestRankAssets tests the scoring of release assets
+func TestRankAssets(t *testing.T) {
+	t.Parallel()
+
+	assets := func(names ...string) []*github.ReleaseAsset {
+		var list []*github.ReleaseAsset
+		for _, n := range names {
+			list = append(list, &github.ReleaseAsset{Name: stringPtr(n), BrowserDownloadURL: stringPtr("https://example.com/" + n)})
+		}
+		return list
+	}
+	cfg := func(goos, arch string) *Config {
+		return &Config{
+			OS:          goos,
+			Arch:        arch,
+			OSAliases:   map[string][]string{"darwin": {"macos"}},
+			ArchAliases: map[string][]string{"amd64": {"x86_64"}, "arm64": {"aarch64"}},
+		}
+	}
+
+	testCases := []struct {
+		name     string
+		cfg      *Config
+		assets   []*github.ReleaseAsset
+		expected string // empty if nothing matches
+	}{
+		{
+			name:     "arch inside another platform",
+			cfg:      cfg("linux", "arm64"),
+			assets:   assets("tool_darwin-arm64.tar.gz", "tool_linux_amd64.tar.gz"),
+			expected: "",
+		},
+		{
+			name:     "arch variant is no match",
+			cfg:      cfg("linux", "amd64"),
+			assets:   assets("tool_linux_amd64v3.tar.gz", "tool_linux_amd64.tar.gz"),
+			expected: "tool_linux_amd64.tar.gz",
+		},
+		{
+			name:     "sbom and signatures are skipped",
+			cfg:      cfg("linux", "amd64"),
+			assets:   assets("tool_linux_amd64.tar.gz.sbom", "tool_linux_amd64.tar.gz.sig", "tool_linux_amd64.tar.gz"),
+			expected: "tool_linux_amd64.tar.gz",
+		},
+		{
+			name:     "source archives are skipped",
+			cfg:      cfg("linux", "amd64"),
+			assets:   assets("tool_src_linux_amd64.tar.gz", "tool_linux_amd64.msi"),
+			expected: "",
+		},
+		{
+			name:     "linux packages are the last resort",
+			cfg:      cfg("linux", "amd64"),
+			assets:   assets("tool_1.0_amd64.deb", "tool-1.0.x86_64.rpm", "tool-x86_64.AppImage", "tool_linux_amd64.tar.gz"),
+			expected: "tool_linux_amd64.tar.gz",
+		},
+		{
+			name:     "deb without os token",
+			cfg:      cfg("linux", "amd64"),
+			assets:   assets("tool_1.0_arm64.deb", "tool_1.0_amd64.deb"),
+			expected: "tool_1.0_amd64.deb",
+		},
+		{
+			name:     "linux packages only on linux",
+			cfg:      cfg("darwin", "amd64"),
+			assets:   assets("tool_1.0_amd64.deb", "tool-x86_64.AppImage"),
+			expected: "",
+		},
+		{
+			name:     "debug builds lose",
+			cfg:      cfg("linux", "amd64"),
+			assets:   assets("tool-debug_linux_amd64.tar.gz", "tool_linux_amd64.tar.gz"),
+			expected: "tool_linux_amd64.tar.gz",
+		},
+		{
+			name:     "debug build as last resort",
+			cfg:      cfg("linux", "amd64"),
+			assets:   assets("tool-debug_linux_amd64.tar.gz"),
+			expected: "tool-debug_linux_amd64.tar.gz",
+		},
+		{
+			name:     "exact token beats alias",
+			cfg:      cfg("darwin", "amd64"),
+			assets:   assets("tool-macos-x86_64.tar.gz", "tool-darwin-x86_64.tar.gz", "tool-darwin-amd64.tar.gz"),
+			expected: "tool-darwin-amd64.tar.gz",
+		},
+		{
+			name:     "x86-64 is joined",
+			cfg:      cfg("linux", "amd64"),
+			assets:   assets("tool-linux-x86-64.zip"),
+			expected: "tool-linux-x86-64.zip",
+		},
+		{
+			name:     "repository name preferred",
+			cfg:      cfg("linux", "amd64"),
+			assets:   assets("helper_linux_amd64.tar.gz", "tool_linux_amd64.tar.gz"),
+			expected: "tool_linux_amd64.tar.gz",
+		},
+		{
+			name:     "raw binary",
+			cfg:      cfg("linux", "amd64"),
+			assets:   assets("tool-1.2.0-linux-amd64", "tool-1.2.0-linux-amd64.sha256", "tool-1.2.0-darwin-amd64"),
+			expected: "tool-1.2.0-linux-amd64",
+		},
+		{
+			name:     "archive preferred over raw binary",
+			cfg:      cfg("linux", "amd64"),
+			assets:   assets("tool_linux_amd64", "tool_linux_amd64.tar.gz"),
+			expected: "tool_linux_amd64.tar.gz",
+		},
+		{
+			name:     "unknown extension is no raw binary",
+			cfg:      cfg("linux", "amd64"),
+			assets:   assets("tool_linux_amd64.dmg", "tool_linux_amd64.exe"),
+			expected: "",
+		},
+		{
+			name:     "gnu host prefers gnu",
+			cfg:      withLibc(cfg("linux", "amd64"), LibcGNU),
+			assets:   assets("tool-x86_64-unknown-linux-musl.tar.gz", "tool-x86_64-unknown-linux-gnu.tar.gz"),
+			expected: "tool-x86_64-unknown-linux-gnu.tar.gz",
+		},
+		{
+			name:     "musl host prefers musl",
+			cfg:      withLibc(cfg("linux", "amd64"), LibcMusl),
+			assets:   assets("tool-x86_64-unknown-linux-gnu.tar.gz", "tool-x86_64-unknown-linux-musl.tar.gz"),
+			expected: "tool-x86_64-unknown-linux-musl.tar.gz",
+		},
+		{
+			name:     "musl host falls back to static",
+			cfg:      withLibc(cfg("linux", "amd64"), LibcMusl),
+			assets:   assets("tool-linux-amd64-glibc.tar.gz", "tool-linux-amd64-static.tar.gz"),
+			expected: "tool-linux-amd64-static.tar.gz",
+		},
+		{
+			name:     "musl host avoids gnu",
+			cfg:      withLibc(cfg("linux", "amd64"), LibcMusl),
+			assets:   assets("tool-linux-amd64-gnu.tar.gz", "tool-linux-amd64.tar.gz"),
+			expected: "tool-linux-amd64.tar.gz",
+		},
+		{
+			name:     "musl is no linux alias",
+			cfg:      cfg("linux", "amd64"),
+			assets:   assets("tool-musl-x86_64.tar.gz"),
+			expected: "",
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			asset, err := parseAsset(tc.assets, tc.cfg, "owner", "tool")
+			if tc.expected == "" {
+				for _, c := range rankAssets(tc.assets, tc.cfg, "tool") {
+					t.Log(c)
+				}
+				assert.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tc.expected, asset.Name)
+		})
+	}
+
+	t.Run("windows", func(t *testing.T) {
+		t.Parallel()
+		defaults, err := DefaultConfig()
+		require.NoError(t, err)
+		windows := defaults.ForPlatform(Platform{OS: "windows", Arch: "amd64"})
+
+		for _, names := range [][]string{
+			{"tool-linux-amd64.tar.gz", "tool-x86_64-pc-windows-msvc.zip"},
+			{"tool-linux-amd64", "tool-windows-amd64.exe"},
+			{"tool-win32.zip", "tool-win64.zip"},
+			{"tool-windows-arm64.zip", "tool-windows-x64.msi", "tool_win_x64.zip"},
+		} {
+			asset, err := parseAsset(assets(names...), windows, "owner", "tool")
+			require.NoError(t, err)
+			assert.Equal(t, names[len(names)-1], asset.Name)
+		}
+	})
+
+	t.Run("tokenize", func(t *testing.T) {
+		t.Parallel()
+		assert.Equal(t, []string{"tool", "1", "2", "x86_64", "unknown", "linux", "musl", "tar", "gz"}, tokenize("Tool-1.2-x86_64-unknown-linux-musl.tar.gz"))
+		assert.Equal(t, []string{"tool", "x86_64", "zip"}, tokenize("tool x86-64.zip"))
+	})
+}
+
+// TestArchMatrix tests the arch aliases and their fallback order
+func TestArchMatrix(t *testing.T) {
+	t.Parallel()
+
+	cfg := func(arch string, armLevel int) *Config {
+		return &Config{OS: "linux", Arch: arch, ArchAliases: defaultArchAliases(armLevel)}
+	}
+	assets := func(names ...string) []*github.ReleaseAsset {
+		var list []*github.ReleaseAsset
+		for _, n := range names {
+			list = append(list, &github.ReleaseAsset{Name: stringPtr(n), BrowserDownloadURL: stringPtr("https://example.com/" + n)})
+		}
+		return list
+	}
+	// a typical release with one asset per platform
+	release := assets(
+		"tool-linux-x86_64.tar.gz", "tool-linux-i686.tar.gz", "tool-linux-aarch64.tar.gz",
+		"tool-linux-armv7.tar.gz", "tool-linux-armv6.tar.gz", "tool-linux-armel.tar.gz",
+		"tool-linux-riscv64.tar.gz", "tool-linux-ppc64le.tar.gz", "tool-linux-s390x.tar.gz",
+		"tool-linux-loong64.tar.gz",
+	)
+
+	testCases := []struct {
+		name     string
+		cfg      *Config
+		assets   []*github.ReleaseAsset
+		expected string // empty if nothing matches
+	}{
+		{"amd64", cfg("amd64", 0), release, "tool-linux-x86_64.tar.gz"},
+		{"386", cfg("386", 0), release, "tool-linux-i686.tar.gz"},
+		{"386 is not x86_64", cfg("386", 0), assets("tool-linux-x86_64.tar.gz", "tool-linux-x86.tar.gz"), "tool-linux-x86.tar.gz"},
+		{"arm64", cfg("arm64", 0), release, "tool-linux-aarch64.tar.gz"},
+		{"armv7", cfg("arm", 7), release, "tool-linux-armv7.tar.gz"},
+		{"armv6", cfg("arm", 6), release, "tool-linux-armv6.tar.gz"},
+		{"armv5", cfg("arm", 5), release, "tool-linux-armel.tar.gz"},
+		{"armv6 build on armv7", cfg("arm", 7), assets("tool-linux-armv6.tar.gz", "tool-linux-arm64.tar.gz"), "tool-linux-armv6.tar.gz"},
+		{"armhf on armv7", cfg("arm", 7), assets("tool-linux-armel.tar.gz", "tool-linux-armhf.tar.gz"), "tool-linux-armhf.tar.gz"},
+		{"armhf not on armv6", cfg("arm", 6), assets("tool-linux-armhf.tar.gz"), ""},
+		{"armv7 not on armv6", cfg("arm", 6), assets("tool-linux-armv7.tar.gz", "tool-linux-arm64.tar.gz"), ""},
+		{"arm is not arm64", cfg("arm", 7), assets("tool-linux-arm64.tar.gz", "tool-linux-arm.tar.gz"), "tool-linux-arm.tar.gz"},
+		{"level beats plain arm", cfg("arm", 7), assets("tool-linux-arm.tar.gz", "tool-linux-armv7l.tar.gz"), "tool-linux-armv7l.tar.gz"},
+		{"riscv64", cfg("riscv64", 0), release, "tool-linux-riscv64.tar.gz"},
+		{"ppc64le", cfg("ppc64le", 0), assets("tool-linux-ppc64.tar.gz", "tool-linux-powerpc64le.tar.gz"), "tool-linux-powerpc64le.tar.gz"},
+		{"s390x", cfg("s390x", 0), release, "tool-linux-s390x.tar.gz"},
+		{"loong64", cfg("loong64", 0), assets("tool-linux-loongarch64.tar.gz", "tool-linux-x86_64.tar.gz"), "tool-linux-loongarch64.tar.gz"},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			asset, err := parseAsset(tc.assets, tc.cfg, "owner", "tool")
+			if tc.expected == "" {
+				assert.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tc.expected, asset.Name)
+		})
+	}
+}
+
+// TestDetectArmLevel tests the ARM level from uname and the GOARM fallback
+func TestDetectArmLevel(t *testing.T) {
+	t.Parallel()
+
+	assert.Equal(t, 7, detectArmLevelFrom("armv7l\n", ""))
+	assert.Equal(t, 6, detectArmLevelFrom("armv6l", "7"))
+	assert.Equal(t, 5, detectArmLevelFrom("armv5tel", ""))
+	assert.Equal(t, 7, detectArmLevelFrom("armv8l", ""))
+	assert.Equal(t, 7, detectArmLevelFrom("aarch64", ""))
+	assert.Equal(t, 6, detectArmLevelFrom("", "6,softfloat"))
+	assert.Equal(t, 6, detectArmLevelFrom("", ""))
+}
+
+func withLibc(cfg *Config, libc string) *Config {
+	cfg.Libc = libc
+	return cfg
+}
+
+// TestDetectLibc tests the libc detection with fake loaders and ldd output
+func TestDetectLibc(t *testing.T) {
+	t.Parallel()
+
+	glob := func(loaders ...string) func(string) ([]string, error) {
+		return func(pattern string) ([]string, error) {
+			var matches []string
+			for _, l := range loaders {
+				if ok, _ := filepath.Match(pattern, l); ok {
+					matches = append(matches, l)
+				}
+			}
+			return matches, nil
+		}
+	}
+	ldd := func(out string, err error) func() (string, error) {
+		return func() (string, error) { return out, err }
+	}
+
+	testCases := []struct {
+		name     string
+		glob     func(string) ([]string, error)
+		ldd      func() (string, error)
+		expected string
+	}{
+		{"musl loader", glob("/lib/ld-musl-x86_64.so.1"), ldd("", nil), LibcMusl},
+		{"glibc loader", glob("/lib64/ld-linux-x86-64.so.2"), ldd("", nil), LibcGNU},
+		{"multiarch glibc loader", glob("/lib/aarch64-linux-gnu/ld-linux-aarch64.so.1"), ldd("", nil), LibcGNU},
+		{"ldd of glibc", glob(), ldd("ldd (Ubuntu GLIBC 2.39-0ubuntu8) 2.39", nil), LibcGNU},
+		{"ldd of musl", glob(), ldd("musl libc (x86_64)\nVersion 1.2.4", nil), LibcMusl},
+		{"no ldd", glob(), ldd("", errors.New("not found")), ""},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+			assert.Equal(t, tc.expected, detectLibcFrom(tc.glob, tc.ldd))
+		})
+	}
+}
+
+// TestAssetPattern tests globs, regular expressions and placeholders of asset patterns
+func TestAssetPattern(t *testing.T) {
+	t.Parallel()
+
+	cfg := &Config{
+		OS:          "darwin",
+		Arch:        "arm64",
+		OSAliases:   map[string][]string{"darwin": {"macos"}},
+		ArchAliases: map[string][]string{"arm64": {"aarch64"}},
+	}
+
+	testCases := []struct {
+		pattern string
+		name    string
+		match   bool
+	}{
+		{"tool-*-{{os}}-{{arch}}.tar.gz", "Tool-1.0-macOS-aarch64.tar.gz", true},
+		{"tool-*-{{os}}-{{arch}}.tar.gz", "tool-1.0-linux-arm64.tar.gz", false},
+		{"tool-{{version}}-{{os}}.zip", "tool-1.2.3-darwin.zip", true},
+		{"tool-{{version}}-{{os}}.zip", "tool-v1.2.3-darwin.zip", true},
+		{"tool-{{ version }}-{{os}}.zip", "tool-1.2.4-darwin.zip", false},
+		{"re:^tool-{{os}}-(arm64|universal)\\.tar\\.gz$", "tool-macos-universal.tar.gz", true},
+		{"re:{{arch}}", "tool-darwin-aarch64.tar.gz", true},
+		{"re:^{{arch}}", "tool-darwin-aarch64.tar.gz", false},
+	}
+
+	for _, tc := range testCases {
+		match, err := compileAssetPattern(tc.pattern, cfg, "v1.2.3")
+		require.NoError(t, err, tc.pattern)
+		assert.Equal(t, tc.match, match(tc.name), "%s ~ %s", tc.pattern, tc.name)
+	}
+
+	for _, invalid := range []string{"tool-[", "re:tool-(", "tool-{{platform}}"} {
+		assert.Error(t, ValidateAssetPattern(invalid), invalid)
+	}
+
+	t.Run("pattern overrides ranking", func(t *testing.T) {
+		t.Parallel()
+
+		assets := []*github.ReleaseAsset{
+			{Name: stringPtr("tool-darwin-arm64.tar.gz"), BrowserDownloadURL: stringPtr("https://example.com/a")},
+			{Name: stringPtr("tool-full-mac.zip"), BrowserDownloadURL: stringPtr("https://example.com/b")},
+		}
+		match, err := compileAssetPattern("*-full-mac.zip", cfg, "v1.0.0")
+		require.NoError(t, err)
+
+		asset, err := selectAsset(assets, cfg, "owner", "tool", "v1.0.0", match, nil)
+		require.NoError(t, err)
+		assert.Equal(t, "tool-full-mac.zip", asset.Name)
+
+		match, err = compileAssetPattern("*-windows.zip", cfg, "v1.0.0")
+		require.NoError(t, err)
+		_, err = selectAsset(assets, cfg, "owner", "tool", "v1.0.0", match, nil)
+		assert.ErrorContains(t, err, "no asset matches the asset pattern")
+		_, err = selectAssetQuietly(assets, cfg, "tool", match)
+		assert.ErrorContains(t, err, "no asset matches the asset pattern")
+	})
+
+	t.Run("quietly selects like selectAsset", func(t *testing.T) {
+		t.Parallel()
+
+		linux := cfg.ForPlatform(Platform{OS: "linux", Arch: "amd64"})
+		assets := []*github.ReleaseAsset{
+			{Name: stringPtr("tool_linux_amd64.zip")},
+			{Name: stringPtr("tool_linux_amd64.tar.gz")},
+			{Name: stringPtr("tool_darwin_arm64.tar.gz")},
+		}
+		asset, err := selectAsset(assets, linux, "owner", "tool", "v1.0.0", nil, nil)
+		require.NoError(t, err)
+		quiet, err := selectAssetQuietly(assets, linux, "tool", nil)
+		require.NoError(t, err)
+		assert.Equal(t, asset.Name, quiet.GetName())
+
+		_, err = selectAssetQuietly(assets[2:], linux, "tool", nil)
+		assert.EqualError(t, err, "no asset found for linux_amd64")
+	})
+}
+
 // stringPtr is a helper function to create string pointers for test data
 func stringPtr(s string) *string {
 	return &s
@@ -493,7 +937,7 @@ func createMaliciousTarGz(t *testing.T, entryName string) []byte {
 }
 
 // createMaliciousZip creates a zip archive with a path traversal entry.
-func createMaliciousZip(t *testing.T, entryName string) []byte {
+func createMaliciousZip(t testing.TB, entryName string) []byte {
 	t.Helper()
 	var buf bytes.Buffer
 	zw := zip.NewWriter(&buf)
@@ -601,16 +1045,127 @@ func machOBinary() []byte {
 	}
 }
 
+// elfBinary returns a minimal ELF executable header for the machine
+func elfBinary(class elf.Class, data elf.Data, machine elf.Machine, abi elf.OSABI) []byte {
+	var order binary.ByteOrder = binary.LittleEndian
+	if data == elf.ELFDATA2MSB {
+		order = binary.BigEndian
+	}
+	ident := [elf.EI_NIDENT]byte{0x7f, 'E', 'L', 'F', byte(class), byte(data), byte(elf.EV_CURRENT), byte(abi)}
+
+	var buf bytes.Buffer
+	if class == elf.ELFCLASS32 {
+		_ = binary.Write(&buf, order, elf.Header32{Ident: ident, Type: uint16(elf.ET_EXEC), Machine: uint16(machine), Version: uint32(elf.EV_CURRENT), Ehsize: 52})
+	} else {
+		_ = binary.Write(&buf, order, elf.Header64{Ident: ident, Type: uint16(elf.ET_EXEC), Machine: uint16(machine), Version: uint32(elf.EV_CURRENT), Ehsize: 64})
+	}
+	return append(buf.Bytes(), make([]byte, 1000)...)
+}
+
+// peBinary returns a minimal PE executable, or DLL, for the machine
+func peBinary(machine uint16, dll bool) []byte {
+	const peOffset = 0x40
+	header := pe.FileHeader{Machine: machine, Characteristics: pe.IMAGE_FILE_EXECUTABLE_IMAGE}
+	if dll {
+		header.Characteristics |= pe.IMAGE_FILE_DLL
+	}
+
+	buf := bytes.NewBuffer(make([]byte, peOffset))
+	copy(buf.Bytes(), "MZ")
+	binary.LittleEndian.PutUint32(buf.Bytes()[0x3c:], peOffset)
+	buf.WriteString("PE\x00\x00")
+	_ = binary.Write(buf, binary.LittleEndian, header)
+	return append(buf.Bytes(), make([]byte, 1000)...)
+}
+
+// machOHeader returns a minimal 64-bit Mach-O executable header for the CPU
+func machOHeader(cpu macho.Cpu) []byte {
+	var buf bytes.Buffer
+	_ = binary.Write(&buf, binary.LittleEndian, macho.FileHeader{Magic: macho.Magic64, Cpu: cpu, Type: macho.TypeExec})
+	return append(buf.Bytes(), 0, 0, 0, 0) // reserved
+}
+
+// fatMachO returns a universal Mach-O binary with one header per CPU
+func fatMachO(cpus ...macho.Cpu) []byte {
+	const align = 12 // 4096 bytes
+	var buf bytes.Buffer
+	_ = binary.Write(&buf, binary.BigEndian, []uint32{macho.MagicFat, uint32(len(cpus))})
+	for i, cpu := range cpus {
+		_ = binary.Write(&buf, binary.BigEndian, []uint32{uint32(cpu), 0, uint32(i+1) << align, 32, align})
+	}
+	for i, cpu := range cpus {
+		buf.Write(make([]byte, (i+1)<<align-buf.Len()))
+		buf.Write(machOHeader(cpu))
+	}
+	return buf.Bytes()
+}
+
+// TestCheckBinaryPlatform tests the machine and OS checks of ELF and Mach-O executables
+func TestCheckBinaryPlatform(t *testing.T) {
+	t.Parallel()
+
+	testCases := []struct {
+		name    string
+		content []byte
+		os      string
+		arch    string
+		wantErr string // empty if the binary matches
+	}{
+		{"linux amd64", elfBinary(elf.ELFCLASS64, elf.ELFDATA2LSB, elf.EM_X86_64, elf.ELFOSABI_NONE), "linux", "amd64", ""},
+		{"gnu os abi", elfBinary(elf.ELFCLASS64, elf.ELFDATA2LSB, elf.EM_X86_64, elf.ELFOSABI_LINUX), "linux", "amd64", ""},
+		{"arm64 on amd64", elfBinary(elf.ELFCLASS64, elf.ELFDATA2LSB, elf.EM_AARCH64, elf.ELFOSABI_NONE), "linux", "amd64", "is built for arm64, not linux/amd64"},
+		{"amd64 on arm64", elfBinary(elf.ELFCLASS64, elf.ELFDATA2LSB, elf.EM_X86_64, elf.ELFOSABI_NONE), "linux", "arm64", "is built for amd64, not linux/arm64"},
+		{"386 on amd64", elfBinary(elf.ELFCLASS32, elf.ELFDATA2LSB, elf.EM_386, elf.ELFOSABI_NONE), "linux", "amd64", "is built for 386"},
+		{"arm", elfBinary(elf.ELFCLASS32, elf.ELFDATA2LSB, elf.EM_ARM, elf.ELFOSABI_NONE), "linux", "arm", ""},
+		{"ppc64le", elfBinary(elf.ELFCLASS64, elf.ELFDATA2LSB, elf.EM_PPC64, elf.ELFOSABI_NONE), "linux", "ppc64le", ""},
+		{"big endian ppc64 on ppc64le", elfBinary(elf.ELFCLASS64, elf.ELFDATA2MSB, elf.EM_PPC64, elf.ELFOSABI_NONE), "linux", "ppc64le", "is built for ppc64"},
+		{"s390x", elfBinary(elf.ELFCLASS64, elf.ELFDATA2MSB, elf.EM_S390, elf.ELFOSABI_NONE), "linux", "s390x", ""},
+		{"freebsd on linux", elfBinary(elf.ELFCLASS64, elf.ELFDATA2LSB, elf.EM_X86_64, elf.ELFOSABI_FREEBSD), "linux", "amd64", "is built for freebsd"},
+		{"freebsd", elfBinary(elf.ELFCLASS64, elf.ELFDATA2LSB, elf.EM_X86_64, elf.ELFOSABI_FREEBSD), "freebsd", "amd64", ""},
+		{"elf on darwin", elfBinary(elf.ELFCLASS64, elf.ELFDATA2LSB, elf.EM_AARCH64, elf.ELFOSABI_NONE), "darwin", "arm64", "is an ELF binary"},
+		{"darwin arm64", machOHeader(macho.CpuArm64), "darwin", "arm64", ""},
+		{"darwin amd64 on arm64", machOHeader(macho.CpuAmd64), "darwin", "arm64", "is built for amd64, not darwin/arm64"},
+		{"mach-o on linux", machOHeader(macho.CpuAmd64), "linux", "amd64", "is a Mach-O binary"},
+		{"universal", fatMachO(macho.CpuAmd64, macho.CpuArm64), "darwin", "arm64", ""},
+		{"universal without arch", fatMachO(macho.CpuAmd64, macho.Cpu386), "darwin", "arm64", "is built for amd64, 386"},
+		{"windows amd64", peBinary(pe.IMAGE_FILE_MACHINE_AMD64, false), "windows", "amd64", ""},
+		{"windows arm64 on amd64", peBinary(pe.IMAGE_FILE_MACHINE_ARM64, false), "windows", "amd64", "is built for arm64, not windows/amd64"},
+		{"pe on linux", peBinary(pe.IMAGE_FILE_MACHINE_AMD64, false), "linux", "amd64", "is a Windows binary"},
+		{"elf on windows", elfBinary(elf.ELFCLASS64, elf.ELFDATA2LSB, elf.EM_X86_64, elf.ELFOSABI_NONE), "windows", "amd64", "is an ELF binary"},
+		{"script", []byte("#!/bin/sh\necho tool\n"), "linux", "amd64", ""},
+		{"unknown arch", elfBinary(elf.ELFCLASS64, elf.ELFDATA2LSB, elf.EM_MIPS, elf.ELFOSABI_NONE), "linux", "mips64le", ""},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			path := filepath.Join(t.TempDir(), "tool")
+			require.NoError(t, os.WriteFile(path, tc.content, 0o755))
+
+			err := CheckBinaryPlatform(path, tc.os, tc.arch)
+			if tc.wantErr == "" {
+				assert.NoError(t, err)
+				return
+			}
+			assert.ErrorIs(t, err, ErrWrongPlatform)
+			assert.ErrorContains(t, err, "tool "+tc.wantErr)
+		})
+	}
+}
+
 // tarEntry describes a single entry for newTarStream.
 type tarEntry struct {
 	name    string
 	content []byte
 	mode    int64
 	isDir   bool
+	symlink string // symlink to this target
+	link    string // hardlink to this entry
 }
 
 // newTarStream builds an in-memory tar stream from the given entries.
-func newTarStream(t *testing.T, entries []tarEntry) *bytes.Reader {
+func newTarStream(t testing.TB, entries []tarEntry) *bytes.Reader {
 	t.Helper()
 	var buf bytes.Buffer
 	tw := tar.NewWriter(&buf)
@@ -624,14 +1179,21 @@ func newTarStream(t *testing.T, entries []tarEntry) *bytes.Reader {
 			}
 		}
 		typeflag := byte(tar.TypeReg)
-		if e.isDir {
+		linkname := ""
+		switch {
+		case e.isDir:
 			typeflag = tar.TypeDir
+		case e.symlink != "":
+			typeflag, linkname = tar.TypeSymlink, e.symlink
+		case e.link != "":
+			typeflag, linkname = tar.TypeLink, e.link
 		}
 		hdr := &tar.Header{
 			Name:     e.name,
 			Mode:     mode,
 			Size:     int64(len(e.content)),
 			Typeflag: typeflag,
+			Linkname: linkname,
 		}
 		require.NoError(t, tw.WriteHeader(hdr))
 		if len(e.content) > 0 {
@@ -648,9 +1210,14 @@ func silentBar() *progressbar.ProgressBar {
 	return progressbar.NewOptions(-1, progressbar.OptionSetWriter(io.Discard))
 }
 
+// newSource returns data as the source of an unpackFn
+func newSource(data []byte) *io.SectionReader {
+	return io.NewSectionReader(bytes.NewReader(data), 0, int64(len(data)))
+}
+
 // bzip2Compress compresses data using the system bzip2 command.
 // The calling test is skipped when bzip2 is not present on the host.
-func bzip2Compress(t *testing.T, data []byte) []byte {
+func bzip2Compress(t testing.TB, data []byte) []byte {
 	t.Helper()
 	bzip2Bin, err := exec.LookPath("bzip2")
 	if err != nil {
@@ -697,7 +1264,7 @@ func createTestTarXz(t *testing.T) []byte {
 }
 
 // createTestZipWithExec builds an in-memory .zip containing a mock Mach-O executable.
-func createTestZipWithExec(t *testing.T) []byte {
+func createTestZipWithExec(t testing.TB) []byte {
 	t.Helper()
 	var buf bytes.Buffer
 	zw := zip.NewWriter(&buf)
@@ -721,7 +1288,7 @@ func TestUnpackTar(t *testing.T) {
 		dest := t.TempDir()
 		require.NoError(t, unpackTar(newTarStream(t, []tarEntry{
 			{name: "hello.txt", content: []byte("hello world")},
-		}), dest))
+		}), newExtractor(dest)))
 		got, err := os.ReadFile(filepath.Join(dest, "hello.txt"))
 		require.NoError(t, err)
 		assert.Equal(t, "hello world", string(got))
@@ -733,7 +1300,7 @@ func TestUnpackTar(t *testing.T) {
 		require.NoError(t, unpackTar(newTarStream(t, []tarEntry{
 			{name: "mydir/", isDir: true},
 			{name: "mydir/file.txt", content: []byte("inside")},
-		}), dest))
+		}), newExtractor(dest)))
 		assert.DirExists(t, filepath.Join(dest, "mydir"))
 		got, err := os.ReadFile(filepath.Join(dest, "mydir", "file.txt"))
 		require.NoError(t, err)
@@ -745,7 +1312,7 @@ func TestUnpackTar(t *testing.T) {
 		dest := t.TempDir()
 		require.NoError(t, unpackTar(newTarStream(t, []tarEntry{
 			{name: "a/b/c/deep.txt", content: []byte("deep")},
-		}), dest))
+		}), newExtractor(dest)))
 		got, err := os.ReadFile(filepath.Join(dest, "a", "b", "c", "deep.txt"))
 		require.NoError(t, err)
 		assert.Equal(t, "deep", string(got))
@@ -756,7 +1323,7 @@ func TestUnpackTar(t *testing.T) {
 		dest := t.TempDir()
 		require.NoError(t, unpackTar(newTarStream(t, []tarEntry{
 			{name: "run.sh", content: []byte("#!/bin/sh"), mode: 0o755},
-		}), dest))
+		}), newExtractor(dest)))
 		info, err := os.Stat(filepath.Join(dest, "run.sh"))
 		require.NoError(t, err)
 		assert.NotZero(t, info.Mode().Perm()&0o111, "execute bits should be preserved for mode 0755")
@@ -767,7 +1334,7 @@ func TestUnpackTar(t *testing.T) {
 		dest := t.TempDir()
 		require.NoError(t, unpackTar(newTarStream(t, []tarEntry{
 			{name: "config.txt", content: []byte("key=val"), mode: 0o600},
-		}), dest))
+		}), newExtractor(dest)))
 		info, err := os.Stat(filepath.Join(dest, "config.txt"))
 		require.NoError(t, err)
 		assert.Zero(t, info.Mode().Perm()&0o111, "execute bits must not be set for mode 0600")
@@ -783,7 +1350,7 @@ func TestUnpackTar(t *testing.T) {
 		// 0o6755 = setuid + setgid + rwxr-xr-x
 		require.NoError(t, unpackTar(newTarStream(t, []tarEntry{
 			{name: "suid-sgid", content: []byte("data"), mode: 0o6755},
-		}), dest))
+		}), newExtractor(dest)))
 		info, err := os.Stat(filepath.Join(dest, "suid-sgid"))
 		require.NoError(t, err)
 		assert.Zero(t, info.Mode()&os.ModeSetuid, "setuid bit must not be set on extracted file")
@@ -795,7 +1362,7 @@ func TestUnpackTar(t *testing.T) {
 		dest := t.TempDir()
 		require.NoError(t, unpackTar(newTarStream(t, []tarEntry{
 			{name: "suiddir/", isDir: true, mode: 0o4755},
-		}), dest))
+		}), newExtractor(dest)))
 		info, err := os.Stat(filepath.Join(dest, "suiddir"))
 		require.NoError(t, err)
 		assert.Zero(t, info.Mode()&os.ModeSetuid, "setuid bit must not be set on extracted directory")
@@ -808,7 +1375,7 @@ func TestUnpackTar(t *testing.T) {
 			{name: "bin/", isDir: true},
 			{name: "bin/tool", content: []byte("binary"), mode: 0o755},
 			{name: "etc/config.toml", content: []byte("k=v"), mode: 0o644},
-		}), dest))
+		}), newExtractor(dest)))
 		assert.FileExists(t, filepath.Join(dest, "bin", "tool"))
 		assert.FileExists(t, filepath.Join(dest, "etc", "config.toml"))
 	})
@@ -818,7 +1385,7 @@ func TestUnpackTar(t *testing.T) {
 		dest := t.TempDir()
 		err := unpackTar(newTarStream(t, []tarEntry{
 			{name: "../escape.txt", content: []byte("evil")},
-		}), dest)
+		}), newExtractor(dest))
 		assert.Error(t, err)
 		assert.Contains(t, err.Error(), "path traversal attempt")
 		_, statErr := os.Stat(filepath.Join(filepath.Dir(dest), "escape.txt"))
@@ -836,7 +1403,7 @@ func TestUnpackTar(t *testing.T) {
 		_ = tw.WriteHeader(&tar.Header{Name: "/etc/passwd", Mode: 0o644, Size: int64(len(content))})
 		_, _ = tw.Write(content)
 		_ = tw.Close()
-		err := unpackTar(&buf, dest)
+		err := unpackTar(&buf, newExtractor(dest))
 		assert.Error(t, err)
 		assert.Contains(t, err.Error(), "path traversal attempt")
 	})
@@ -857,7 +1424,7 @@ func TestUnpackTarGzDirect(t *testing.T) {
 	require.NoError(t, tw.Close())
 	require.NoError(t, gw.Close())
 
-	require.NoError(t, unpackTarGz(bytes.NewReader(buf.Bytes()), dest, silentBar()))
+	require.NoError(t, unpackTarGz(newSource(buf.Bytes()), newExtractor(dest)))
 	got, err := os.ReadFile(filepath.Join(dest, "hello.txt"))
 	require.NoError(t, err)
 	assert.Equal(t, "hello from tar.gz", string(got))
@@ -879,7 +1446,7 @@ func TestUnpackTarXzDirect(t *testing.T) {
 	require.NoError(t, tw.Close())
 	require.NoError(t, xw.Close())
 
-	require.NoError(t, unpackTarXz(bytes.NewReader(buf.Bytes()), dest, silentBar()))
+	require.NoError(t, unpackTarXz(newSource(buf.Bytes()), newExtractor(dest)))
 	got, err := os.ReadFile(filepath.Join(dest, "hello.txt"))
 	require.NoError(t, err)
 	assert.Equal(t, "hello from tar.xz", string(got))
@@ -900,7 +1467,7 @@ func TestUnpackTarBz2Direct(t *testing.T) {
 	require.NoError(t, tw.Close())
 
 	compressed := bzip2Compress(t, tarBuf.Bytes())
-	require.NoError(t, unpackTarBz2(bytes.NewReader(compressed), dest, silentBar()))
+	require.NoError(t, unpackTarBz2(newSource(compressed), newExtractor(dest)))
 	got, err := os.ReadFile(filepath.Join(dest, "hello.txt"))
 	require.NoError(t, err)
 	assert.Equal(t, "hello from tar.bz2", string(got))
@@ -915,7 +1482,9 @@ func TestUnpackBz2Direct(t *testing.T) {
 	content := []byte("hello from raw bz2")
 	compressed := bzip2Compress(t, content)
 	outPath := filepath.Join(dest, "hello.txt")
-	require.NoError(t, unpackBz2(bytes.NewReader(compressed), outPath, silentBar()))
+	x := newExtractor(dest)
+	x.single = "hello.txt"
+	require.NoError(t, unpackBz2(newSource(compressed), x))
 	got, err := os.ReadFile(outPath)
 	require.NoError(t, err)
 	assert.Equal(t, "hello from raw bz2", string(got))
@@ -953,7 +1522,7 @@ func TestUnpackZipDirect(t *testing.T) {
 
 	require.NoError(t, zw.Close())
 
-	require.NoError(t, unpackZip(bytes.NewReader(buf.Bytes()), dest, silentBar()))
+	require.NoError(t, unpackZip(newSource(buf.Bytes()), newExtractor(dest)))
 	assert.DirExists(t, filepath.Join(dest, "subdir"))
 	got, err := os.ReadFile(filepath.Join(dest, "subdir", "hello.txt"))
 	require.NoError(t, err)
@@ -963,6 +1532,39 @@ func TestUnpackZipDirect(t *testing.T) {
 	assert.Equal(t, "root file", string(got2))
 }
 
+// TestUnpackProgress verifies that the progress bar counts the extracted
+// bytes and knows the total for zip archives.
+func TestUnpackProgress(t *testing.T) {
+	t.Parallel()
+	content := bytes.Repeat([]byte("grip"), 1024)
+
+	t.Run("zip", func(t *testing.T) {
+		var buf bytes.Buffer
+		zw := zip.NewWriter(&buf)
+		for _, name := range []string{"a.txt", "b.txt"} {
+			w, err := zw.Create(name)
+			require.NoError(t, err)
+			_, err = w.Write(content)
+			require.NoError(t, err)
+		}
+		require.NoError(t, zw.Close())
+
+		x := newExtractor(t.TempDir())
+		x.bar = silentBar()
+		require.NoError(t, unpackZip(newSource(buf.Bytes()), x))
+		assert.Equal(t, int64(2*len(content)), x.bar.GetMax64())
+		assert.Equal(t, float64(2*len(content)), x.bar.State().CurrentBytes)
+	})
+
+	t.Run("tar.gz", func(t *testing.T) {
+		archive := gzipBytes(t, newTarStream(t, []tarEntry{{name: "a.txt", content: content}}))
+		x := newExtractor(t.TempDir())
+		x.bar = silentBar()
+		require.NoError(t, unpackTarGz(newSource(archive), x))
+		assert.Equal(t, float64(len(content)), x.bar.State().CurrentBytes)
+	})
+}
+
 // TestUnpackerNoExecutableFound verifies that Unpacker.Unpack returns an error
 // when no executable binary is present in the archive.
 func TestUnpackerNoExecutableFound(t *testing.T) {
@@ -1029,3 +1631,701 @@ func TestUnpackerUnpackTarBz2(t *testing.T) {
 	assert.NotEmpty(t, execPath)
 	assert.FileExists(t, execPath)
 }
+
+// compress compresses data in the single file format of ext
+func compress(t testing.TB, ext string, data []byte) []byte {
+	t.Helper()
+	var buf bytes.Buffer
+	var w io.WriteCloser
+	var err error
+	switch ext {
+	case ".gz":
+		w = gzip.NewWriter(&buf)
+	case ".xz":
+		w, err = xz.NewWriter(&buf)
+	case ".zst":
+		w, err = zstd.NewWriter(&buf)
+	case ".bz2":
+		return bzip2Compress(t, data)
+	default:
+		t.Fatalf("unknown compression %s", ext)
+	}
+	require.NoError(t, err)
+	_, err = w.Write(data)
+	require.NoError(t, err)
+	require.NoError(t, w.Close())
+	return buf.Bytes()
+}
+
+// create7z builds a 7z archive holding a single stored (uncompressed) file
+func create7z(t testing.TB, name string, content []byte, mode os.FileMode) []byte {
+	t.Helper()
+	require.Less(t, len(content), 1<<14, "number encoding supports up to 2 bytes")
+
+	number := func(b *bytes.Buffer, v int) {
+		if v < 0x80 {
+			b.WriteByte(byte(v))
+			return
+		}
+		b.WriteByte(0x80 | byte(v>>8))
+		b.WriteByte(byte(v))
+	}
+
+	var header bytes.Buffer
+	header.WriteByte(0x01) // kHeader
+	header.WriteByte(0x04) // kMainStreamsInfo
+	header.WriteByte(0x06) // kPackInfo
+	number(&header, 0)     // pack position
+	number(&header, 1)     // pack streams
+	header.WriteByte(0x09) // kSize
+	number(&header, len(content))
+	header.WriteByte(0x00)
+	header.WriteByte(0x07)              // kUnPackInfo
+	header.Write([]byte{0x0b, 1, 0})    // kFolder, 1 folder, not external
+	header.Write([]byte{1, 0x01, 0x00}) // 1 coder, id size 1, Copy
+	header.WriteByte(0x0c)              // kCodersUnPackSize
+	number(&header, len(content))
+	header.WriteByte(0x00)
+	header.Write([]byte{0x08, 0x00}) // kSubStreamsInfo
+	header.WriteByte(0x00)
+
+	header.WriteByte(0x05) // kFilesInfo
+	number(&header, 1)
+	utf16 := make([]byte, 0, 2*len(name)+2)
+	for _, r := range name {
+		utf16 = append(utf16, byte(r), byte(r>>8))
+	}
+	utf16 = append(utf16, 0, 0)
+	header.WriteByte(0x11) // kName
+	number(&header, 1+len(utf16))
+	header.WriteByte(0) // not external
+	header.Write(utf16)
+	header.WriteByte(0x15) // kAttributes
+	number(&header, 6)
+	header.Write([]byte{1, 0}) // all defined, not external
+	_ = binary.Write(&header, binary.LittleEndian, uint32(0x8000)|uint32(mode.Perm()|0o100000)<<16)
+	header.WriteByte(0x00)
+	header.WriteByte(0x00)
+
+	start := make([]byte, 20)
+	binary.LittleEndian.PutUint64(start[0:], uint64(len(content)))
+	binary.LittleEndian.PutUint64(start[8:], uint64(header.Len()))
+	binary.LittleEndian.PutUint32(start[16:], crc32.ChecksumIEEE(header.Bytes()))
+
+	var archive bytes.Buffer
+	archive.Write([]byte{'7', 'z', 0xbc, 0xaf, 0x27, 0x1c, 0, 4})
+	_ = binary.Write(&archive, binary.LittleEndian, crc32.ChecksumIEEE(start))
+	archive.Write(start)
+	archive.Write(content)
+	archive.Write(header.Bytes())
+	return archive.Bytes()
+}
+
+// TestUnpackTarZstDirect tests unpackTarZst directly, bypassing Unpack.
+func TestUnpackTarZstDirect(t *testing.T) {
+	t.Parallel()
+	dest := t.TempDir()
+
+	stream := newTarStream(t, []tarEntry{{name: "hello.txt", content: []byte("hello from tar.zst")}})
+	tarData, err := io.ReadAll(stream)
+	require.NoError(t, err)
+
+	require.NoError(t, unpackTarZst(newSource(compress(t, ".zst", tarData)), newExtractor(dest)))
+	got, err := os.ReadFile(filepath.Join(dest, "hello.txt"))
+	require.NoError(t, err)
+	assert.Equal(t, "hello from tar.zst", string(got))
+}
+
+// TestUnpack7zDirect tests unpack7z directly, bypassing Unpack.
+func TestUnpack7zDirect(t *testing.T) {
+	t.Parallel()
+	dest := t.TempDir()
+
+	archive := create7z(t, "bin/hello", []byte("hello from 7z"), 0o755)
+	require.NoError(t, unpack7z(newSource(archive), newExtractor(dest)))
+
+	got, err := os.ReadFile(filepath.Join(dest, "bin", "hello"))
+	require.NoError(t, err)
+	assert.Equal(t, "hello from 7z", string(got))
+	info, err := os.Stat(filepath.Join(dest, "bin", "hello"))
+	require.NoError(t, err)
+	assert.Equal(t, os.FileMode(0o755), info.Mode().Perm())
+
+	err = unpack7z(newSource(create7z(t, "../evil", []byte("x"), 0o644)), newExtractor(t.TempDir()))
+	assert.ErrorContains(t, err, "path traversal")
+}
+
+// TestUnpackCompressedFileDirect tests the single file formats directly.
+func TestUnpackCompressedFileDirect(t *testing.T) {
+	t.Parallel()
+
+	for ext, fn := range map[string]unpackFn{".gz": unpackGz, ".xz": unpackXz, ".zst": unpackZst} {
+		t.Run(ext, func(t *testing.T) {
+			t.Parallel()
+			x := newExtractor(t.TempDir())
+			x.single = "hello.txt"
+			outPath := filepath.Join(x.dest, "hello.txt")
+
+			content := []byte("hello from " + ext)
+			require.NoError(t, fn(newSource(compress(t, ext, content)), x))
+			got, err := os.ReadFile(outPath)
+			require.NoError(t, err)
+			assert.Equal(t, content, got)
+		})
+	}
+}
+
+// TestUnpackerFormats tests Unpack end-to-end for every format, including
+// archives detected by content when the extension is missing or misleading.
+func TestUnpackerFormats(t *testing.T) {
+	t.Parallel()
+
+	binary := append(machOBinary(), make([]byte, 1000)...)
+	tarData, err := io.ReadAll(newTarStream(t, []tarEntry{
+		{name: "tool-1.0/README.md", content: []byte("# tool")},
+		{name: "tool-1.0/tool", content: binary, mode: 0o755},
+	}))
+	require.NoError(t, err)
+
+	testCases := []struct {
+		name    string
+		content []byte
+	}{
+		{"tool.tar", tarData},
+		{"tool.tar.zst", compress(t, ".zst", tarData)},
+		{"tool.tzst", compress(t, ".zst", tarData)},
+		{"tool.txz", compress(t, ".xz", tarData)},
+		{"tool.7z", create7z(t, "tool-1.0/tool", binary, 0o755)},
+		{"tool-linux-amd64.gz", compress(t, ".gz", binary)},
+		{"tool-linux-amd64.xz", compress(t, ".xz", binary)},
+		{"tool-linux-amd64.zst", compress(t, ".zst", binary)},
+		{"tool-linux-amd64.bz2", compress(t, ".bz2", binary)},
+		{"misleading.zip", compress(t, ".gz", tarData)},
+		{"tool-linux-amd64", compress(t, ".zst", tarData)},
+		{"tool-linux-amd64-gz", compress(t, ".gz", binary)},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+			tempDir := t.TempDir()
+
+			archivePath := filepath.Join(tempDir, tc.name)
+			require.NoError(t, os.WriteFile(archivePath, tc.content, 0o644))
+
+			execPath, err := Unpack(archivePath, filepath.Join(tempDir, "out"))
+			require.NoError(t, err)
+			got, err := os.ReadFile(execPath)
+			require.NoError(t, err)
+			assert.Equal(t, binary, got)
+		})
+	}
+}
+
+// TestDetectArchiveExt tests detecting archive formats by their leading bytes
+func TestDetectArchiveExt(t *testing.T) {
+	t.Parallel()
+
+	tarData, err := io.ReadAll(newTarStream(t, []tarEntry{{name: "tool", content: []byte("tool")}}))
+	require.NoError(t, err)
+
+	testCases := []struct {
+		content  []byte
+		expected string
+	}{
+		{tarData, ".tar"},
+		{compress(t, ".gz", tarData), ".tar.gz"},
+		{compress(t, ".xz", tarData), ".tar.xz"},
+		{compress(t, ".zst", tarData), ".tar.zst"},
+		{compress(t, ".gz", []byte("tool")), ".gz"},
+		{compress(t, ".zst", []byte("tool")), ".zst"},
+		{createTestZipWithExec(t), ".zip"},
+		{create7z(t, "tool", []byte("tool"), 0o755), ".7z"},
+		{machOBinary(), ""},
+		{[]byte("#!/bin/sh\n"), ""},
+	}
+
+	for _, tc := range testCases {
+		path := filepath.Join(t.TempDir(), "asset")
+		require.NoError(t, os.WriteFile(path, tc.content, 0o644))
+		ext, err := detectArchiveExt(path)
+		require.NoError(t, err)
+		assert.Equal(t, tc.expected, ext)
+	}
+}
+
+// createDeb builds a Debian package whose data.tar is compressed with ext
+func createDeb(t testing.TB, ext string, entries []tarEntry) []byte {
+	t.Helper()
+	data, err := io.ReadAll(newTarStream(t, entries))
+	require.NoError(t, err)
+	if ext != "" {
+		data = compress(t, ext, data)
+	}
+	control := compress(t, ".gz", []byte{})
+
+	var buf bytes.Buffer
+	buf.WriteString("!<arch>\n")
+	for _, m := range []struct {
+		name string
+		data []byte
+	}{
+		{"debian-binary", []byte("2.0\n")},
+		{"control.tar.gz", control},
+		{"data.tar" + ext, data},
+	} {
+		fmt.Fprintf(&buf, "%-16s%-12d%-6d%-6d%-8s%-10d`\n", m.name, 0, 0, 0, "100644", len(m.data))
+		buf.Write(m.data)
+		if len(m.data)%2 == 1 {
+			buf.WriteByte('\n')
+		}
+	}
+	return buf.Bytes()
+}
+
+// cpioEntry describes a single entry for createRpm
+type cpioEntry struct {
+	name    string
+	content []byte
+	mode    int64 // unix mode including the file type
+}
+
+// createRpm builds an RPM package whose cpio payload is compressed with ext
+func createRpm(t testing.TB, ext string, entries []cpioEntry) []byte {
+	t.Helper()
+	pad := func(b *bytes.Buffer, align int) {
+		for b.Len()%align != 0 {
+			b.WriteByte(0)
+		}
+	}
+
+	var cpio bytes.Buffer
+	for _, e := range append(entries, cpioEntry{name: "TRAILER!!!"}) {
+		fmt.Fprintf(&cpio, "070701%08x%08x%08x%08x%08x%08x%08x%08x%08x%08x%08x%08x%08x",
+			0, e.mode, 0, 0, 1, 0, len(e.content), 0, 0, 0, 0, len(e.name)+1, 0)
+		cpio.WriteString(e.name + "\x00")
+		pad(&cpio, 4)
+		cpio.Write(e.content)
+		pad(&cpio, 4)
+	}
+
+	header := func(b *bytes.Buffer, entries, dataSize int) {
+		b.Write([]byte{0x8e, 0xad, 0xe8, 0x01, 0, 0, 0, 0})
+		_ = binary.Write(b, binary.BigEndian, []uint32{uint32(entries), uint32(dataSize)})
+		b.Write(make([]byte, entries*16+dataSize))
+	}
+
+	var buf bytes.Buffer
+	lead := make([]byte, 96)
+	copy(lead, []byte{0xed, 0xab, 0xee, 0xdb, 3, 0})
+	buf.Write(lead)
+	header(&buf, 1, 5) // signature, padded to 8 bytes
+	pad(&buf, 8)
+	header(&buf, 2, 7)
+	if ext == "" {
+		buf.Write(cpio.Bytes())
+	} else {
+		buf.Write(compress(t, ext, cpio.Bytes()))
+	}
+	return buf.Bytes()
+}
+
+// TestUnpackDebDirect tests unpackDeb directly for every data.tar compression.
+func TestUnpackDebDirect(t *testing.T) {
+	t.Parallel()
+
+	for _, ext := range []string{"", ".gz", ".xz", ".zst", ".bz2"} {
+		t.Run("data.tar"+ext, func(t *testing.T) {
+			t.Parallel()
+			dest := t.TempDir()
+
+			deb := createDeb(t, ext, []tarEntry{
+				{name: "./usr/", isDir: true},
+				{name: "./usr/bin/tool", content: []byte("hello from deb"), mode: 0o755},
+			})
+			require.NoError(t, unpackDeb(newSource(deb), newExtractor(dest)))
+			got, err := os.ReadFile(filepath.Join(dest, "usr", "bin", "tool"))
+			require.NoError(t, err)
+			assert.Equal(t, "hello from deb", string(got))
+		})
+	}
+
+	t.Run("absolute symlink", func(t *testing.T) {
+		t.Parallel()
+		dest := t.TempDir()
+
+		deb := createDeb(t, ".gz", []tarEntry{
+			{name: "./opt/tool/bin/tool", content: []byte("hello from deb"), mode: 0o755},
+			{name: "./usr/bin/tool", symlink: "/opt/tool/bin/tool"},
+			{name: "./usr/bin/evil", symlink: "/../../outside"},
+		})
+		require.NoError(t, unpackDeb(newSource(deb), newExtractor(dest)))
+
+		link, err := os.Readlink(filepath.Join(dest, "usr", "bin", "tool"))
+		require.NoError(t, err)
+		assert.Equal(t, filepath.Join("..", "..", "opt", "tool", "bin", "tool"), link)
+		got, err := os.ReadFile(filepath.Join(dest, "usr", "bin", "tool"))
+		require.NoError(t, err)
+		assert.Equal(t, "hello from deb", string(got))
+
+		link, err = os.Readlink(filepath.Join(dest, "usr", "bin", "evil"))
+		require.NoError(t, err)
+		assert.Equal(t, "../../outside", filepath.ToSlash(link), "clamped to the root")
+	})
+
+	t.Run("not a deb", func(t *testing.T) {
+		t.Parallel()
+		err := unpackDeb(newSource([]byte("PK\x03\x04")), newExtractor(t.TempDir()))
+		assert.ErrorIs(t, err, ErrInvalidAsset)
+	})
+}
+
+// TestUnpackRpmDirect tests unpackRpm directly for every payload compression.
+func TestUnpackRpmDirect(t *testing.T) {
+	t.Parallel()
+
+	for _, ext := range []string{"", ".gz", ".xz", ".zst"} {
+		t.Run("cpio"+ext, func(t *testing.T) {
+			t.Parallel()
+			dest := t.TempDir()
+
+			rpm := createRpm(t, ext, []cpioEntry{
+				{name: "./usr/bin", mode: 0o40755},
+				{name: "./usr/bin/tool", content: []byte("hello from rpm"), mode: 0o100755},
+				{name: "./usr/bin/link", content: []byte("tool"), mode: 0o120777},
+			})
+			require.NoError(t, unpackRpm(newSource(rpm), newExtractor(dest)))
+			got, err := os.ReadFile(filepath.Join(dest, "usr", "bin", "tool"))
+			require.NoError(t, err)
+			assert.Equal(t, "hello from rpm", string(got))
+			info, err := os.Stat(filepath.Join(dest, "usr", "bin", "tool"))
+			require.NoError(t, err)
+			assert.Equal(t, os.FileMode(0o755), info.Mode().Perm())
+			link, err := os.Readlink(filepath.Join(dest, "usr", "bin", "link"))
+			require.NoError(t, err)
+			assert.Equal(t, "tool", link)
+		})
+	}
+
+	t.Run("path traversal", func(t *testing.T) {
+		t.Parallel()
+		rpm := createRpm(t, ".gz", []cpioEntry{{name: "../evil", content: []byte("x"), mode: 0o100644}})
+		err := unpackRpm(newSource(rpm), newExtractor(t.TempDir()))
+		assert.ErrorContains(t, err, "path traversal")
+	})
+}
+
+// TestUnpackerPackages tests Unpack end-to-end for deb and rpm packages.
+func TestUnpackerPackages(t *testing.T) {
+	t.Parallel()
+
+	binary := elfBinary(elf.ELFCLASS64, elf.ELFDATA2LSB, elf.EM_X86_64, elf.ELFOSABI_NONE)
+	testCases := map[string][]byte{
+		"tool_1.0_amd64.deb": createDeb(t, ".xz", []tarEntry{
+			{name: "./usr/share/doc/tool/copyright", content: []byte("MIT")},
+			{name: "./usr/bin/tool", content: binary, mode: 0o755},
+		}),
+		"tool-1.0.x86_64.rpm": createRpm(t, ".zst", []cpioEntry{
+			{name: "./usr/share/doc/tool/copyright", content: []byte("MIT"), mode: 0o100644},
+			{name: "./usr/bin/tool", content: binary, mode: 0o100755},
+		}),
+	}
+
+	for name, content := range testCases {
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+			tempDir := t.TempDir()
+
+			archivePath := filepath.Join(tempDir, name)
+			require.NoError(t, os.WriteFile(archivePath, content, 0o644))
+
+			execPath, err := Unpack(archivePath, filepath.Join(tempDir, "out"))
+			require.NoError(t, err)
+			assert.Equal(t, filepath.Join(tempDir, "out", "usr", "bin", "tool"), execPath)
+		})
+	}
+}
+
+// TestShareDest tests detecting man pages and shell completions in archives
+func TestShareDest(t *testing.T) {
+	t.Parallel()
+
+	testCases := []struct {
+		entry    string
+		expected string
+	}{
+		{"doc/rg.1", "man/man1/rg.1"},
+		{"man/tool-sub.5.gz", "man/man5/tool-sub.5.gz"},
+		{"complete/rg.bash", "bash-completion/completions/rg"},
+		{"tool.bash-completion", "bash-completion/completions/tool"},
+		{"completions/bash/tool", "bash-completion/completions/tool"},
+		{"complete/_rg", "zsh/site-functions/_rg"},
+		{"completions/tool.zsh", "zsh/site-functions/_tool"},
+		{"completions/zsh/tool", "zsh/site-functions/_tool"},
+		{"complete/rg.fish", "fish/vendor_completions.d/rg.fish"},
+		{"tool-1.2.1", ""},
+		{"README.md", ""},
+		{"tool", ""},
+		{"lib/__init__.py", ""},
+	}
+
+	for _, tc := range testCases {
+		assert.Equal(t, tc.expected, filepath.ToSlash(shareDest(tc.entry)), tc.entry)
+	}
+}
+
+// TestFindExecutable tests choosing the executable of archives with several candidates
+func TestFindExecutable(t *testing.T) {
+	t.Parallel()
+
+	exe := elfBinary(elf.ELFCLASS64, elf.ELFDATA2LSB, elf.EM_X86_64, elf.ELFOSABI_NONE)
+	large := append(bytes.Clone(exe), make([]byte, 4*len(exe))...)
+	script := []byte("#!/bin/sh\nexec tool \"$@\"\n")
+
+	testCases := []struct {
+		name     string
+		entries  []tarEntry
+		scripts  bool
+		expected string
+		err      error
+		contains string
+	}{
+		{
+			name: "name matches the repository",
+			entries: []tarEntry{
+				{name: "a-helper", content: large, mode: 0o755},
+				{name: "tool", content: exe, mode: 0o755},
+			},
+			expected: "tool",
+		},
+		{
+			name: "name starts with the repository",
+			entries: []tarEntry{
+				{name: "helper", content: exe, mode: 0o755},
+				{name: "tool-linux-amd64", content: exe, mode: 0o755},
+			},
+			expected: "tool-linux-amd64",
+		},
+		{
+			name: "executable bit",
+			entries: []tarEntry{
+				{name: "data", content: exe, mode: 0o644},
+				{name: "run", content: exe, mode: 0o755},
+			},
+			expected: "run",
+		},
+		{
+			name: "shallow over fixtures",
+			entries: []tarEntry{
+				{name: "pkg/testdata/tool", content: exe, mode: 0o755},
+				{name: "pkg/tool", content: exe, mode: 0o755},
+			},
+			expected: "pkg/tool",
+		},
+		{
+			name: "larger binary",
+			entries: []tarEntry{
+				{name: "helper", content: exe, mode: 0o755},
+				{name: "server", content: large, mode: 0o755},
+			},
+			expected: "server",
+		},
+		{
+			name: "nothing obvious",
+			entries: []tarEntry{
+				{name: "one", content: exe, mode: 0o755},
+				{name: "two", content: exe, mode: 0o755},
+			},
+			err:      ErrAmbiguousBin,
+			contains: "one, two",
+		},
+		{
+			name: "scripts are opt-in",
+			entries: []tarEntry{
+				{name: "bin/tool", content: script, mode: 0o755},
+			},
+			err:      ErrNoExecutable,
+			contains: "--scripts",
+		},
+		{
+			name: "script",
+			entries: []tarEntry{
+				{name: "README.md", content: []byte("# tool")},
+				{name: "bin/tool", content: script, mode: 0o755},
+			},
+			scripts:  true,
+			expected: "bin/tool",
+		},
+		{
+			name: "binaries rank above scripts",
+			entries: []tarEntry{
+				{name: "tool", content: exe, mode: 0o755},
+				{name: "tool.sh", content: script, mode: 0o755},
+			},
+			scripts:  true,
+			expected: "tool",
+		},
+		{
+			name: "windows executable",
+			entries: []tarEntry{
+				{name: "tool/tool.exe", content: peBinary(pe.IMAGE_FILE_MACHINE_AMD64, false), mode: 0o644},
+				{name: "tool/libtool.dll", content: append(peBinary(pe.IMAGE_FILE_MACHINE_AMD64, true), make([]byte, 4096)...), mode: 0o644},
+			},
+			expected: "tool/tool.exe",
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+			dir := t.TempDir()
+			require.NoError(t, unpackTar(newTarStream(t, tc.entries), newExtractor(dir)))
+
+			path, err := findExecutable(dir, []string{"", "tool"}, tc.scripts)
+			if tc.err != nil {
+				require.ErrorIs(t, err, tc.err)
+				assert.ErrorContains(t, err, tc.contains)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, filepath.Join(dir, filepath.FromSlash(tc.expected)), path)
+		})
+	}
+}
+
+// TestUnpackLinks tests extracting symlinks and hardlinks that stay inside the destination
+func TestUnpackLinks(t *testing.T) {
+	t.Parallel()
+
+	t.Run("links inside", func(t *testing.T) {
+		t.Parallel()
+		dest := t.TempDir()
+		require.NoError(t, unpackTar(newTarStream(t, []tarEntry{
+			{name: "libexec/tool-1.0", content: []byte("tool"), mode: 0o755},
+			{name: "bin/tool", symlink: "../libexec/tool-1.0"},
+			{name: "bin/tool-hard", link: "libexec/tool-1.0"},
+			{name: "current", symlink: "libexec"},
+			{name: "current/notes", content: []byte("notes")},
+		}), newExtractor(dest)))
+
+		for _, name := range []string{"bin/tool", "bin/tool-hard", "current/tool-1.0"} {
+			got, err := os.ReadFile(filepath.Join(dest, filepath.FromSlash(name)))
+			require.NoError(t, err, name)
+			assert.Equal(t, "tool", string(got))
+		}
+		assert.FileExists(t, filepath.Join(dest, "libexec", "notes"))
+	})
+
+	testCases := []struct {
+		name    string
+		entries []tarEntry
+		err     error
+	}{
+		{"symlink to parent", []tarEntry{{name: "evil", symlink: "../outside"}}, ErrUnsafePath},
+		{"absolute symlink", []tarEntry{{name: "evil", symlink: "/etc/passwd"}}, ErrUnsafePath},
+		{"symlink through symlink", []tarEntry{
+			{name: "a", symlink: "."},
+			{name: "evil", symlink: "a/../.."},
+		}, ErrUnsafePath},
+		{"nested symlink to parent", []tarEntry{
+			{name: "dir", symlink: "."},
+			{name: "dir/evil", symlink: ".."},
+		}, ErrUnsafePath},
+		{"hardlink to parent", []tarEntry{{name: "evil", link: "../outside"}}, ErrUnsafePath},
+		{"hardlink to missing entry", []tarEntry{{name: "evil", link: "missing"}}, ErrInvalidAsset},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+			root := t.TempDir()
+			dest := filepath.Join(root, "dest")
+			require.NoError(t, os.Mkdir(dest, 0o755))
+
+			err := unpackTar(newTarStream(t, tc.entries), newExtractor(dest))
+			require.ErrorIs(t, err, tc.err)
+			var uerr *UnpackError
+			assert.ErrorAs(t, err, &uerr)
+			assert.Equal(t, "evil", filepath.Base(uerr.Entry))
+			assert.NoFileExists(t, filepath.Join(dest, "evil"))
+		})
+	}
+
+	t.Run("zip symlink", func(t *testing.T) {
+		t.Parallel()
+		var buf bytes.Buffer
+		zw := zip.NewWriter(&buf)
+		w, err := zw.Create("tool")
+		require.NoError(t, err)
+		_, err = w.Write([]byte("tool"))
+		require.NoError(t, err)
+		hdr := &zip.FileHeader{Name: "link"}
+		hdr.SetMode(os.ModeSymlink | 0o777)
+		w, err = zw.CreateHeader(hdr)
+		require.NoError(t, err)
+		_, err = w.Write([]byte("tool"))
+		require.NoError(t, err)
+		require.NoError(t, zw.Close())
+
+		dest := t.TempDir()
+		require.NoError(t, unpackZip(newSource(buf.Bytes()), newExtractor(dest)))
+		target, err := os.Readlink(filepath.Join(dest, "link"))
+		require.NoError(t, err)
+		assert.Equal(t, "tool", target)
+	})
+}
+
+// TestUnpackLimits tests rejecting archive bombs
+func TestUnpackLimits(t *testing.T) {
+	t.Parallel()
+
+	zeros := make([]byte, 2<<20)
+	entries := []tarEntry{
+		{name: "a", content: []byte("a")},
+		{name: "b", content: []byte("b")},
+		{name: "c", content: []byte("c")},
+	}
+
+	testCases := []struct {
+		name   string
+		limits UnpackLimits
+		size   int64
+		tar    []tarEntry
+	}{
+		{"entries", UnpackLimits{MaxEntries: 2}, 0, entries},
+		{"size", UnpackLimits{MaxSize: 1 << 20}, 0, []tarEntry{{name: "zeros", content: zeros}}},
+		{"ratio", UnpackLimits{MaxRatio: 100}, 4 << 10, []tarEntry{{name: "zeros", content: zeros}}},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+			x := newExtractor(t.TempDir())
+			x.limits = tc.limits
+			x.archiveSize = tc.size
+			err := unpackTar(newTarStream(t, tc.tar), x)
+			require.ErrorIs(t, err, ErrUnpackLimit)
+			var uerr *UnpackError
+			assert.ErrorAs(t, err, &uerr)
+		})
+	}
+
+	t.Run("within limits", func(t *testing.T) {
+		t.Parallel()
+		x := newExtractor(t.TempDir())
+		x.archiveSize = 4 << 10
+		require.NoError(t, unpackTar(newTarStream(t, entries), x))
+	})
+
+	t.Run("gzip bomb end-to-end", func(t *testing.T) {
+		t.Parallel()
+		dir := t.TempDir()
+		archivePath := filepath.Join(dir, "bomb.tar.gz")
+		bomb := gzipBytes(t, newTarStream(t, []tarEntry{{name: "zeros", content: make([]byte, 8<<20), mode: 0o755}}))
+		require.NoError(t, os.WriteFile(archivePath, bomb, 0o644))
+
+		_, err := Unpack(archivePath, filepath.Join(dir, "out"))
+		require.ErrorIs(t, err, ErrUnpackLimit)
+		assert.ErrorContains(t, err, "compression ratio")
+	})
+}
@@ -0,0 +1,187 @@
+package grip
+
+import (
+	"debug/elf"
+	"debug/macho"
+	"debug/pe"
+	"errors"
+	"fmt"
+	"path/filepath"
+	"slices"
+	"strings"
+)
+
+// elfTarget is the ELF header of executables for an architecture
+type elfTarget struct {
+	machine elf.Machine
+	class   elf.Class
+	data    elf.Data
+}
+
+// elfTargets maps architectures to their ELF header
+var elfTargets = map[string]elfTarget{
+	"386":     {elf.EM_386, elf.ELFCLASS32, elf.ELFDATA2LSB},
+	"amd64":   {elf.EM_X86_64, elf.ELFCLASS64, elf.ELFDATA2LSB},
+	"arm":     {elf.EM_ARM, elf.ELFCLASS32, elf.ELFDATA2LSB},
+	"arm64":   {elf.EM_AARCH64, elf.ELFCLASS64, elf.ELFDATA2LSB},
+	"riscv64": {elf.EM_RISCV, elf.ELFCLASS64, elf.ELFDATA2LSB},
+	"ppc64":   {elf.EM_PPC64, elf.ELFCLASS64, elf.ELFDATA2MSB},
+	"ppc64le": {elf.EM_PPC64, elf.ELFCLASS64, elf.ELFDATA2LSB},
+	"s390x":   {elf.EM_S390, elf.ELFCLASS64, elf.ELFDATA2MSB},
+	"loong64": {elf.EM_LOONGARCH, elf.ELFCLASS64, elf.ELFDATA2LSB},
+}
+
+// elfOSABIs maps operating systems to the ELF OS ABI of their executables.
+// Most executables don't set it, ELFOSABI_NONE passes for every ELF system.
+var elfOSABIs = map[string]elf.OSABI{
+	"linux":   elf.ELFOSABI_LINUX,
+	"freebsd": elf.ELFOSABI_FREEBSD,
+	"netbsd":  elf.ELFOSABI_NETBSD,
+	"openbsd": elf.ELFOSABI_OPENBSD,
+	"solaris": elf.ELFOSABI_SOLARIS,
+}
+
+// machoCPUs maps architectures to the Mach-O CPU type
+var machoCPUs = map[string]macho.Cpu{
+	"386":   macho.Cpu386,
+	"amd64": macho.CpuAmd64,
+	"arm":   macho.CpuArm,
+	"arm64": macho.CpuArm64,
+	"ppc64": macho.CpuPpc64,
+}
+
+// peMachines maps architectures to the PE machine type of Windows executables
+var peMachines = map[string]uint16{
+	"386":   pe.IMAGE_FILE_MACHINE_I386,
+	"amd64": pe.IMAGE_FILE_MACHINE_AMD64,
+	"arm":   pe.IMAGE_FILE_MACHINE_ARMNT,
+	"arm64": pe.IMAGE_FILE_MACHINE_ARM64,
+}
+
+// CheckBinaryPlatform checks that the executable at path is built for goos
+// and goarch. Files that are neither ELF, Mach-O nor PE, e.g. scripts, pass,
+// as do architectures without a known machine type.
+func CheckBinaryPlatform(path, goos, goarch string) error {
+	if f, err := elf.Open(path); err == nil {
+		defer f.Close()
+		return checkELF(f, filepath.Base(path), goos, goarch)
+	}
+
+	if f, err := pe.Open(path); err == nil {
+		defer f.Close()
+		return checkPE(f.Machine, filepath.Base(path), goos, goarch)
+	}
+
+	fat, err := macho.OpenFat(path)
+	if err == nil {
+		defer fat.Close()
+		var cpus []macho.Cpu
+		for _, a := range fat.Arches {
+			cpus = append(cpus, a.Cpu)
+		}
+		return checkMachO(cpus, filepath.Base(path), goos, goarch)
+	}
+	if errors.Is(err, macho.ErrNotFat) {
+		if f, err := macho.Open(path); err == nil {
+			defer f.Close()
+			return checkMachO([]macho.Cpu{f.Cpu}, filepath.Base(path), goos, goarch)
+		}
+	}
+	return nil
+}
+
+// checkELF compares the machine and OS ABI of an ELF executable
+func checkELF(f *elf.File, name, goos, goarch string) error {
+	switch goos {
+	case "darwin", "ios", "windows":
+		return wrongPlatform(name, "an ELF binary", goos, goarch)
+	}
+	if want, ok := elfOSABIs[goos]; ok && f.OSABI != elf.ELFOSABI_NONE && f.OSABI != want {
+		return wrongPlatform(name, "built for "+elfOS(f.OSABI), goos, goarch)
+	}
+
+	want, ok := elfTargets[goarch]
+	if !ok {
+		return nil
+	}
+	if f.Machine != want.machine || f.Class != want.class || f.Data != want.data {
+		return wrongPlatform(name, "built for "+elfArch(f), goos, goarch)
+	}
+	return nil
+}
+
+// elfArch returns the architecture of an ELF file, or its machine type
+func elfArch(f *elf.File) string {
+	for arch, t := range elfTargets {
+		if f.Machine == t.machine && f.Class == t.class && f.Data == t.data {
+			return arch
+		}
+	}
+	return f.Machine.String()
+}
+
+// elfOS returns the operating system of an ELF OS ABI, or the OS ABI
+func elfOS(abi elf.OSABI) string {
+	for goos, a := range elfOSABIs {
+		if a == abi {
+			return goos
+		}
+	}
+	return abi.String()
+}
+
+// checkMachO checks that one of the CPU types of a Mach-O executable, or a
+// universal one, matches goarch
+func checkMachO(cpus []macho.Cpu, name, goos, goarch string) error {
+	if goos != "darwin" && goos != "ios" {
+		return wrongPlatform(name, "a Mach-O binary", goos, goarch)
+	}
+
+	want, ok := machoCPUs[goarch]
+	if !ok || slices.Contains(cpus, want) {
+		return nil
+	}
+	var arches []string
+	for _, cpu := range cpus {
+		arches = append(arches, machoArch(cpu))
+	}
+	return wrongPlatform(name, "built for "+strings.Join(arches, ", "), goos, goarch)
+}
+
+// machoArch returns the architecture of a Mach-O CPU type
+func machoArch(cpu macho.Cpu) string {
+	for arch, c := range machoCPUs {
+		if c == cpu {
+			return arch
+		}
+	}
+	return cpu.String()
+}
+
+// checkPE compares the machine type of a Windows executable
+func checkPE(machine uint16, name, goos, goarch string) error {
+	if goos != "windows" {
+		return wrongPlatform(name, "a Windows binary", goos, goarch)
+	}
+
+	want, ok := peMachines[goarch]
+	if !ok || machine == want {
+		return nil
+	}
+	return wrongPlatform(name, "built for "+peArch(machine), goos, goarch)
+}
+
+// peArch returns the architecture of a PE machine type
+func peArch(machine uint16) string {
+	for arch, m := range peMachines {
+		if m == machine {
+			return arch
+		}
+	}
+	return fmt.Sprintf("machine %#x", machine)
+}
+
+// wrongPlatform returns the ErrWrongPlatform error for the named binary
+func wrongPlatform(name, found, goos, goarch string) error {
+	return fmt.Errorf("%w: %s is %s, not %s/%s; use --skip-arch-check to install anyway", ErrWrongPlatform, name, found, goos, goarch)
+}
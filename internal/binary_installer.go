@@ -5,11 +5,18 @@ import (
 	"io"
 	"os"
 	"path/filepath"
+	"strings"
 )
 
 // InstallBinary copies the executable at srcPath into binDir with the given
-// binaryName and sets executable permissions (0755).
+// binaryName and sets executable permissions (0755). Windows executables
+// keep their .exe suffix, see installedName.
 func InstallBinary(srcPath, binDir, binaryName string) error {
+	return installBinary(srcPath, binDir, binaryName, archiveSteps)
+}
+
+// installBinary is InstallBinary numbering the progress bar by steps
+func installBinary(srcPath, binDir, binaryName string, steps []string) error {
 	if binDir == "" {
 		return fmt.Errorf("binary directory cannot be empty")
 	}
@@ -43,14 +50,14 @@ func InstallBinary(srcPath, binDir, binaryName string) error {
 	}
 	defer src.Close()
 
-	destPath := filepath.Join(binDir, binaryName)
+	destPath := filepath.Join(binDir, installedName(srcPath, binaryName))
 	dest, err := os.Create(destPath)
 	if err != nil {
 		return fmt.Errorf("create destination binary: %w", err)
 	}
 	defer dest.Close()
 
-	bar := NewProgressBar(int(srcInfo.Size()), "[cyan][3/3][reset] Installing")
+	bar := NewProgressBar(int(srcInfo.Size()), stepLabel(steps, stepInstall))
 	if _, err = io.Copy(io.MultiWriter(dest, bar), src); err != nil {
 		return fmt.Errorf("copy binary: %w", err)
 	}
@@ -62,3 +69,21 @@ func InstallBinary(srcPath, binDir, binaryName string) error {
 
 	return nil
 }
+
+// installedName returns the name the executable at srcPath is installed as:
+// binaryName, with the .exe suffix if srcPath has it, as Windows runs
+// executables by their suffix
+func installedName(srcPath, binaryName string) string {
+	if strings.EqualFold(filepath.Ext(srcPath), ".exe") {
+		return withExeSuffix(binaryName)
+	}
+	return binaryName
+}
+
+// withExeSuffix appends the .exe suffix to name, unless it has it already
+func withExeSuffix(name string) string {
+	if strings.EqualFold(filepath.Ext(name), ".exe") {
+		return name
+	}
+	return name + ".exe"
+}
@@ -0,0 +1,490 @@
+package grip
+
+import (
+	"archive/tar"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/alexjoedt/grip/internal/logger"
+)
+
+const (
+	bundleVersion       = 1
+	bundleManifestName  = "manifest.json"
+	bundleChecksumsName = "checksums.txt"
+)
+
+// BundleManifest describes the content of an offline bundle
+type BundleManifest struct {
+	Version   int           `json:"version"`
+	CreatedAt time.Time     `json:"createdAt"`
+	Entries   []BundleEntry `json:"entries"`
+}
+
+// BundleEntry is a single release asset packaged in a bundle
+type BundleEntry struct {
+	Name        string    `json:"name"`
+	Alias       string    `json:"alias,omitempty"`
+	Repo        string    `json:"repo"`
+	Tag         string    `json:"tag"`
+	PublishedAt time.Time `json:"publishedAt"`
+	OS          string    `json:"os"`
+	Arch        string    `json:"arch"`
+	Asset       string    `json:"asset"`
+	Path        string    `json:"path"` // slash separated path inside the bundle
+	Size        int64     `json:"size"`
+	SHA256      string    `json:"sha256"`
+
+	AssetPattern string   `json:"assetPattern,omitempty"`
+	Binary       string   `json:"binary,omitempty"`
+	Bins         []string `json:"bins,omitempty"`
+	AllBins      bool     `json:"allBins,omitempty"`
+	Scripts      bool     `json:"scripts,omitempty"`
+}
+
+// bundleSource is a package to export, resolved from an installed name or a repo path
+type bundleSource struct {
+	Name  string
+	Alias string
+	Repo  string
+	Tag   string // empty for the latest release
+
+	AssetPattern string
+	Binary       string
+	Bins         []string
+	AllBins      bool
+	Scripts      bool
+}
+
+// ExportBundle downloads the release assets of the given installed names or
+// repositories for every platform and packages them, together with the
+// release metadata and checksums, into a tar file at out.
+// Installed packages are exported at their installed tag.
+func (i *Installer) ExportBundle(ctx context.Context, names []string, platforms []Platform, out string) error {
+	if len(names) == 0 {
+		return fmt.Errorf("no packages to export")
+	}
+	if len(platforms) == 0 {
+		platforms = []Platform{i.config.Platform()}
+	}
+
+	ws, err := NewWorkspace(i.config.TempDir, "grip-bundle")
+	if err != nil {
+		return fmt.Errorf("create workspace: %w", err)
+	}
+	defer func() {
+		if err := ws.Cleanup(); err != nil {
+			logger.Error("Failed to cleanup workspace: %v", err)
+		}
+	}()
+
+	manifest := &BundleManifest{Version: bundleVersion, CreatedAt: time.Now().UTC()}
+	releases := make(map[string][]byte)
+
+	// Resolve all releases and assets first, so that nothing is downloaded
+	// when a package is unavailable for one of the platforms
+	type exportJob struct {
+		entry *BundleEntry
+		asset *Asset
+	}
+	var jobs []exportJob
+
+	for _, name := range names {
+		src, err := i.resolveBundleSource(name)
+		if err != nil {
+			return err
+		}
+
+		owner, repoName, err := ParseRepoPath(src.Repo)
+		if err != nil {
+			return err
+		}
+
+		release, err := i.fetchRelease(ctx, owner, repoName, src.Tag)
+		if err != nil {
+			return fmt.Errorf("%s: %w", name, err)
+		}
+
+		metadata, err := json.MarshalIndent(release, "", "  ")
+		if err != nil {
+			return fmt.Errorf("encode release metadata: %w", err)
+		}
+		releases[path.Join("releases", owner, repoName+".json")] = metadata
+
+		for _, p := range platforms {
+			cfg := i.config.ForPlatform(p)
+			var match func(string) bool
+			if src.AssetPattern != "" {
+				if match, err = compileAssetPattern(src.AssetPattern, cfg, release.GetTagName()); err != nil {
+					return fmt.Errorf("%s: %w", name, err)
+				}
+			}
+			asset, err := selectAsset(release.Assets, cfg, owner, repoName, release.GetTagName(), match, nil)
+			if err != nil {
+				return fmt.Errorf("%s for %s: %w", name, p, err)
+			}
+
+			jobs = append(jobs, exportJob{
+				asset: asset,
+				entry: &BundleEntry{
+					Name:        src.Name,
+					Alias:       src.Alias,
+					Repo:        src.Repo,
+					Tag:         release.GetTagName(),
+					PublishedAt: release.GetPublishedAt().Time,
+					OS:          p.OS,
+					Arch:        p.Arch,
+					Asset:       asset.Name,
+					Path:        path.Join("assets", owner, repoName, p.OS+"_"+p.Arch, asset.Name),
+
+					AssetPattern: src.AssetPattern,
+					Binary:       src.Binary,
+					Bins:         src.Bins,
+					AllBins:      src.AllBins,
+					Scripts:      src.Scripts,
+				},
+			})
+		}
+	}
+
+	for _, job := range jobs {
+		entry, asset := job.entry, job.asset
+		localPath := filepath.Join(ws.DownloadDir(), filepath.FromSlash(entry.Path))
+
+		logger.Info("Exporting %s for %s/%s", asset.Name, entry.OS, entry.Arch)
+		if err := Download(ctx, i.httpClient, i.config.RewriteURL(asset.DownloadURL), filepath.Dir(localPath), asset.Name); err != nil {
+			return fmt.Errorf("download %s: %w", asset.Name, err)
+		}
+
+		info, err := os.Stat(localPath)
+		if err != nil {
+			return err
+		}
+		sum, err := calculateFileSHA256(localPath)
+		if err != nil {
+			return fmt.Errorf("checksum %s: %w", asset.Name, err)
+		}
+
+		entry.Size = info.Size()
+		entry.SHA256 = sum
+		manifest.Entries = append(manifest.Entries, *entry)
+	}
+
+	if err := writeBundle(out, manifest, releases, ws.DownloadDir()); err != nil {
+		return fmt.Errorf("write bundle: %w", err)
+	}
+
+	logger.Success("Exported %d assets to %s", len(manifest.Entries), out)
+	return nil
+}
+
+// ImportOptions holds bundle import parameters
+type ImportOptions struct {
+	Force       bool   // replace already installed packages
+	Destination string // absolute install directory, defaults to the bin dir
+
+	// Target platform instead of the host's. Packages for another platform
+	// need a destination other than the bin dir and aren't recorded.
+	OS   string
+	Arch string
+}
+
+// ImportBundle installs all packages of the bundle at bundlePath that match
+// the target platform, without network access. Packages that are already
+// installed are skipped unless forced.
+func (i *Installer) ImportBundle(bundlePath string, opts ImportOptions) error {
+	if err := i.checkWritable(); err != nil {
+		return err
+	}
+
+	platform := i.config.Platform().Override(opts.OS, opts.Arch)
+	cross := platform != i.config.Platform()
+	destDir := i.config.BinDir
+	if opts.Destination != "" {
+		if !filepath.IsAbs(opts.Destination) {
+			return fmt.Errorf("%w: %s", ErrNoAbsolutePath, opts.Destination)
+		}
+		destDir = filepath.Clean(opts.Destination)
+	}
+	if cross && destDir == i.config.BinDir {
+		return fmt.Errorf("%w: use --destination to import for %s outside %s", ErrCrossTarget, platform, i.config.BinDir)
+	}
+
+	ws, err := NewWorkspace(i.config.TempDir, "grip-bundle")
+	if err != nil {
+		return fmt.Errorf("create workspace: %w", err)
+	}
+	defer func() {
+		if err := ws.Cleanup(); err != nil {
+			logger.Error("Failed to cleanup workspace: %v", err)
+		}
+	}()
+
+	f, err := os.Open(bundlePath)
+	if err != nil {
+		return fmt.Errorf("open bundle: %w", err)
+	}
+	defer f.Close()
+
+	x := newExtractor(ws.DownloadDir())
+	x.limits = i.config.UnpackLimits
+	if err := unpackTar(f, x); err != nil {
+		return fmt.Errorf("read bundle: %w", err)
+	}
+
+	manifest, err := readBundleManifest(filepath.Join(ws.DownloadDir(), bundleManifestName))
+	if err != nil {
+		return err
+	}
+
+	matched := 0
+	for _, e := range manifest.Entries {
+		if e.OS != platform.OS || e.Arch != platform.Arch {
+			continue
+		}
+		matched++
+
+		// Installations for another platform aren't recorded and don't
+		// replace any
+		var existing *Installation
+		if inst, err := i.storage.Get(e.Name); err == nil && !cross {
+			if !opts.Force {
+				if inst.Tag == e.Tag {
+					logger.Info("%s@%s is already installed", e.Name, e.Tag)
+				} else {
+					logger.Warn("%s version %s is already installed, use --force to replace it with %s", e.Name, inst.Tag, e.Tag)
+				}
+				continue
+			}
+			existing = inst
+		}
+
+		archivePath, err := sanitizePath(ws.DownloadDir(), filepath.FromSlash(e.Path))
+		if err != nil {
+			return err
+		}
+		sum, err := calculateFileSHA256(archivePath)
+		if err != nil {
+			return fmt.Errorf("%s: %w", e.Name, err)
+		}
+		if sum != e.SHA256 {
+			return fmt.Errorf("%s: %w: %s", e.Name, ErrChecksumMismatch, e.Asset)
+		}
+
+		owner, repoName, err := ParseRepoPath(e.Repo)
+		if err != nil {
+			return fmt.Errorf("%s: %w", e.Name, err)
+		}
+
+		asset := &Asset{
+			Name:      e.Asset,
+			Alias:     e.Alias,
+			OS:        e.OS,
+			Arch:      e.Arch,
+			Tag:       e.Tag,
+			RepoOwner: owner,
+			RepoName:  repoName,
+			Pattern:   e.AssetPattern,
+			Binary:    e.Binary,
+			Bins:      e.Bins,
+			AllBins:   e.AllBins,
+			Scripts:   e.Scripts,
+		}
+
+		files, err := i.installArchive(archivePath, filepath.Join(ws.UnpackDir(), owner, repoName), destDir, asset)
+		if err != nil {
+			return fmt.Errorf("%s: %w", e.Name, err)
+		}
+		if cross {
+			logger.Success("%s@%s for %s installed to %s", files.bins[0], e.Tag, platform, destDir)
+			continue
+		}
+		if err := i.saveInstallation(e.Repo, asset, destDir, files); err != nil {
+			return err
+		}
+		if existing != nil {
+			i.removeReplaced(existing, destDir, files)
+		}
+
+		logger.Success("%s@%s installed successfully", e.Name, e.Tag)
+	}
+
+	if matched == 0 {
+		return fmt.Errorf("bundle contains no assets for %s", platform)
+	}
+
+	if !cross && !inPath(destDir) {
+		logger.Warn("The grip path '%s' isn't in PATH", destDir)
+	}
+	return nil
+}
+
+// resolveBundleSource looks up name as an installed package first and
+// falls back to treating it as a repository path.
+func (i *Installer) resolveBundleSource(name string) (*bundleSource, error) {
+	if inst, err := i.storage.Get(name); err == nil {
+		owner, repoName, err := ParseRepoPath(inst.Repo)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", name, err)
+		}
+		return &bundleSource{
+			Name:  inst.Name,
+			Alias: inst.Alias,
+			Repo:  "github.com/" + owner + "/" + repoName,
+			Tag:   inst.Tag,
+
+			AssetPattern: inst.AssetPattern,
+			Binary:       inst.Binary,
+			Bins:         inst.Bins,
+			AllBins:      inst.AllBins,
+			Scripts:      inst.Scripts,
+		}, nil
+	}
+
+	owner, repoName, err := ParseRepoPath(name)
+	if err != nil {
+		return nil, fmt.Errorf("%s is neither installed nor a repository path", name)
+	}
+	src := &bundleSource{
+		Name: repoName,
+		Repo: "github.com/" + owner + "/" + repoName,
+	}
+	if spec, ok := i.config.PackageSpec(src.Repo); ok {
+		src.AssetPattern = spec.AssetPattern
+		src.Binary = spec.Binary
+		if spec.Binary != "" {
+			src.Name = binaryBase(spec.Binary)
+		}
+	}
+	return src, nil
+}
+
+// writeBundle writes the manifest, checksums, release metadata and the
+// downloaded assets from assetDir into a tar file at out.
+func writeBundle(out string, manifest *BundleManifest, releases map[string][]byte, assetDir string) error {
+	tmpPath := out + ".tmp"
+	f, err := os.Create(tmpPath)
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmpPath)
+
+	tw := tar.NewWriter(f)
+
+	manifestData, err := json.MarshalIndent(manifest, "", "  ")
+	if err != nil {
+		f.Close()
+		return err
+	}
+	if err := writeTarFile(tw, bundleManifestName, manifestData, manifest.CreatedAt); err != nil {
+		f.Close()
+		return err
+	}
+
+	var checksums strings.Builder
+	for _, e := range manifest.Entries {
+		fmt.Fprintf(&checksums, "%s  %s\n", e.SHA256, e.Path)
+	}
+	if err := writeTarFile(tw, bundleChecksumsName, []byte(checksums.String()), manifest.CreatedAt); err != nil {
+		f.Close()
+		return err
+	}
+
+	names := make([]string, 0, len(releases))
+	for name := range releases {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		if err := writeTarFile(tw, name, releases[name], manifest.CreatedAt); err != nil {
+			f.Close()
+			return err
+		}
+	}
+
+	for _, e := range manifest.Entries {
+		if err := copyTarFile(tw, e.Path, filepath.Join(assetDir, filepath.FromSlash(e.Path)), manifest.CreatedAt); err != nil {
+			f.Close()
+			return err
+		}
+	}
+
+	if err := tw.Close(); err != nil {
+		f.Close()
+		return err
+	}
+	if err := f.Close(); err != nil {
+		return err
+	}
+
+	return os.Rename(tmpPath, out)
+}
+
+// writeTarFile adds a regular file with the given content to tw
+func writeTarFile(tw *tar.Writer, name string, data []byte, modTime time.Time) error {
+	hdr := &tar.Header{
+		Typeflag: tar.TypeReg,
+		Name:     name,
+		Mode:     0644,
+		Size:     int64(len(data)),
+		ModTime:  modTime,
+	}
+	if err := tw.WriteHeader(hdr); err != nil {
+		return err
+	}
+	_, err := tw.Write(data)
+	return err
+}
+
+// copyTarFile adds the file at srcPath to tw under name
+func copyTarFile(tw *tar.Writer, name, srcPath string, modTime time.Time) error {
+	src, err := os.Open(srcPath)
+	if err != nil {
+		return err
+	}
+	defer src.Close()
+
+	info, err := src.Stat()
+	if err != nil {
+		return err
+	}
+
+	hdr := &tar.Header{
+		Typeflag: tar.TypeReg,
+		Name:     name,
+		Mode:     0644,
+		Size:     info.Size(),
+		ModTime:  modTime,
+	}
+	if err := tw.WriteHeader(hdr); err != nil {
+		return err
+	}
+	_, err = io.Copy(tw, src)
+	return err
+}
+
+// readBundleManifest reads and validates the manifest of an extracted bundle
+func readBundleManifest(path string) (*BundleManifest, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("read bundle manifest: %w", err)
+	}
+
+	var manifest BundleManifest
+	if err := json.Unmarshal(data, &manifest); err != nil {
+		return nil, fmt.Errorf("parse bundle manifest: %w", err)
+	}
+	if manifest.Version != bundleVersion {
+		return nil, fmt.Errorf("unsupported bundle version %d", manifest.Version)
+	}
+
+	return &manifest, nil
+}
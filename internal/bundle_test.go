@@ -0,0 +1,236 @@
+package grip
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/google/go-github/v56/github"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// fakeGitHubClient serves a fixed release and repository for every repository
+type fakeGitHubClient struct {
+	release  *github.RepositoryRelease
+	releases map[string]*github.RepositoryRelease // latest release by owner/name or name, nil for none
+	repo     *github.Repository
+	found    []*github.Repository // search results
+	errs     map[string]error     // latest release errors by repository name
+}
+
+func (f *fakeGitHubClient) GetLatestRelease(ctx context.Context, owner, repo string) (*github.RepositoryRelease, error) {
+	if err, ok := f.errs[repo]; ok {
+		return nil, err
+	}
+	release, ok := f.releases[owner+"/"+repo]
+	if !ok {
+		release, ok = f.releases[repo]
+	}
+	if ok {
+		if release == nil {
+			return nil, fmt.Errorf("latest release of %s/%s: %w", owner, repo, ErrNotFound)
+		}
+		return release, nil
+	}
+	return f.release, nil
+}
+
+func (f *fakeGitHubClient) GetReleaseByTag(ctx context.Context, owner, repo, tag string) (*github.RepositoryRelease, error) {
+	if tag != f.release.GetTagName() {
+		return nil, fmt.Errorf("release %s: %w", tag, ErrNotFound)
+	}
+	return f.release, nil
+}
+
+func (f *fakeGitHubClient) GetRepository(ctx context.Context, owner, repo string) (*github.Repository, error) {
+	if f.repo == nil {
+		return nil, fmt.Errorf("repository %s/%s: %w", owner, repo, ErrNotFound)
+	}
+	return f.repo, nil
+}
+
+func (f *fakeGitHubClient) SearchRepositories(ctx context.Context, query string, limit int) ([]*github.Repository, error) {
+	return f.found[:min(limit, len(f.found))], nil
+}
+
+// newTestInstaller creates an installer with storage and bin dir inside a temp dir
+func newTestInstaller(t *testing.T, gh GitHubClient, client *http.Client, platform Platform) *Installer {
+	t.Helper()
+	home := t.TempDir()
+	cfg := &Config{
+		HomeDir:      home,
+		BinDir:       filepath.Join(home, "bin"),
+		ShareDir:     filepath.Join(home, "share"),
+		UnpackLimits: DefaultUnpackLimits(),
+		StorePath:    filepath.Join(home, "grip.json"),
+		TempDir:      t.TempDir(),
+		OS:           platform.OS,
+		Arch:         platform.Arch,
+		OSAliases:    map[string][]string{"darwin": {"macos"}},
+		ArchAliases:  map[string][]string{"amd64": {"x86_64"}},
+	}
+	storage, err := NewStorage(cfg.StorePath, cfg)
+	require.NoError(t, err)
+	return NewInstaller(cfg, storage, gh, client)
+}
+
+// TestBundleRoundTrip exports a bundle and imports it into another installer
+func TestBundleRoundTrip(t *testing.T) {
+	t.Parallel()
+
+	archive := createTestTarGz(t)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write(archive)
+	}))
+	defer srv.Close()
+
+	gh := &fakeGitHubClient{release: &github.RepositoryRelease{
+		TagName: github.String("v1.2.3"),
+		Assets: []*github.ReleaseAsset{
+			{Name: github.String("tool_darwin_amd64.tar.gz"), BrowserDownloadURL: github.String(srv.URL + "/tool_darwin_amd64.tar.gz")},
+			{Name: github.String("tool_linux_amd64.tar.gz"), BrowserDownloadURL: github.String(srv.URL + "/tool_linux_amd64.tar.gz")},
+		},
+	}}
+
+	darwin := Platform{OS: "darwin", Arch: "amd64"}
+	linux := Platform{OS: "linux", Arch: "amd64"}
+
+	exporter := newTestInstaller(t, gh, srv.Client(), linux)
+	bundlePath := filepath.Join(t.TempDir(), "tools.tar")
+	require.NoError(t, exporter.ExportBundle(context.Background(), []string{"github.com/owner/tool"}, []Platform{darwin, linux}, bundlePath))
+	require.FileExists(t, bundlePath)
+
+	t.Run("installs matching platform", func(t *testing.T) {
+		importer := newTestInstaller(t, nil, nil, darwin)
+		require.NoError(t, importer.ImportBundle(bundlePath, ImportOptions{}))
+
+		inst, err := importer.storage.Get("tool")
+		require.NoError(t, err)
+		assert.Equal(t, "v1.2.3", inst.Tag)
+		assert.Equal(t, "github.com/owner/tool", inst.Repo)
+		assert.NotEmpty(t, inst.SHA256)
+		assert.FileExists(t, filepath.Join(importer.config.BinDir, "tool"))
+	})
+
+	t.Run("installs another platform into a destination", func(t *testing.T) {
+		importer := newTestInstaller(t, nil, nil, linux)
+		err := importer.ImportBundle(bundlePath, ImportOptions{OS: "darwin"})
+		require.ErrorIs(t, err, ErrCrossTarget)
+
+		dest := filepath.Join(t.TempDir(), "darwin")
+		require.NoError(t, importer.ImportBundle(bundlePath, ImportOptions{OS: "darwin", Destination: dest}))
+		assert.FileExists(t, filepath.Join(dest, "tool"))
+		assert.NoFileExists(t, filepath.Join(importer.config.BinDir, "tool"))
+
+		_, err = importer.storage.Get("tool")
+		assert.Error(t, err)
+	})
+
+	t.Run("force replaces an installation", func(t *testing.T) {
+		importer := newTestInstaller(t, nil, nil, darwin)
+		binDir := importer.config.BinDir
+		manPage := filepath.Join(importer.config.ShareDir, "man", "man1", "old.1")
+		for _, path := range []string{filepath.Join(binDir, "tool"), filepath.Join(binDir, "helper"), manPage} {
+			require.NoError(t, os.MkdirAll(filepath.Dir(path), 0o755))
+			require.NoError(t, os.WriteFile(path, []byte("old"), 0o755))
+		}
+		require.NoError(t, importer.storage.Save(&Installation{
+			Name: "tool", Repo: "github.com/owner/tool", Tag: "v1.0.0", InstallPath: binDir,
+			Bins: []string{"helper"}, Files: []string{"tool", "helper"}, ShareFiles: []string{manPage},
+		}))
+
+		require.NoError(t, importer.ImportBundle(bundlePath, ImportOptions{}))
+		inst, err := importer.storage.Get("tool")
+		require.NoError(t, err)
+		assert.Equal(t, "v1.0.0", inst.Tag, "not replaced without force")
+
+		require.NoError(t, importer.ImportBundle(bundlePath, ImportOptions{Force: true}))
+		inst, err = importer.storage.Get("tool")
+		require.NoError(t, err)
+		assert.Equal(t, "v1.2.3", inst.Tag)
+		assert.FileExists(t, filepath.Join(binDir, "tool"))
+		assert.NoFileExists(t, filepath.Join(binDir, "helper"))
+		assert.NoFileExists(t, manPage)
+	})
+
+	t.Run("keeps repositories of the same name apart", func(t *testing.T) {
+		release := func(owner, tag string) *github.RepositoryRelease {
+			return &github.RepositoryRelease{TagName: github.String(tag), Assets: []*github.ReleaseAsset{
+				{Name: github.String("tool_linux_amd64.tar.gz"), BrowserDownloadURL: github.String(srv.URL + "/" + owner)},
+			}}
+		}
+		gh := &fakeGitHubClient{releases: map[string]*github.RepositoryRelease{
+			"first/tool":  release("first", "v1.0.0"),
+			"second/tool": release("second", "v2.0.0"),
+		}}
+		exporter := newTestInstaller(t, gh, srv.Client(), linux)
+		bundlePath := filepath.Join(t.TempDir(), "tools.tar")
+		require.NoError(t, exporter.ExportBundle(context.Background(), []string{"github.com/first/tool", "github.com/second/tool"}, nil, bundlePath))
+
+		dir := t.TempDir()
+		f, err := os.Open(bundlePath)
+		require.NoError(t, err)
+		defer f.Close()
+		require.NoError(t, unpackTar(f, newExtractor(dir)))
+
+		manifest, err := readBundleManifest(filepath.Join(dir, bundleManifestName))
+		require.NoError(t, err)
+		require.Len(t, manifest.Entries, 2)
+		assert.Equal(t, "assets/first/tool/linux_amd64/tool_linux_amd64.tar.gz", manifest.Entries[0].Path)
+		assert.Equal(t, "assets/second/tool/linux_amd64/tool_linux_amd64.tar.gz", manifest.Entries[1].Path)
+		for owner, tag := range map[string]string{"first": "v1.0.0", "second": "v2.0.0"} {
+			metadata, err := os.ReadFile(filepath.Join(dir, "releases", owner, "tool.json"))
+			require.NoError(t, err)
+			assert.Contains(t, string(metadata), tag)
+		}
+	})
+
+	t.Run("rejects unknown platform", func(t *testing.T) {
+		importer := newTestInstaller(t, nil, nil, Platform{OS: "freebsd", Arch: "amd64"})
+		err := importer.ImportBundle(bundlePath, ImportOptions{})
+		assert.ErrorContains(t, err, "no assets for freebsd/amd64")
+	})
+
+	t.Run("rejects tampered asset", func(t *testing.T) {
+		importer := newTestInstaller(t, nil, nil, darwin)
+
+		manifest := &BundleManifest{Version: bundleVersion, Entries: []BundleEntry{{
+			Name: "tool", Repo: "github.com/owner/tool", Tag: "v1.2.3", OS: "darwin", Arch: "amd64",
+			Asset: "tool.tar.gz", Path: "assets/tool.tar.gz", SHA256: "0000",
+		}}}
+		assetDir := t.TempDir()
+		require.NoError(t, os.MkdirAll(filepath.Join(assetDir, "assets"), 0755))
+		require.NoError(t, os.WriteFile(filepath.Join(assetDir, "assets", "tool.tar.gz"), archive, 0644))
+
+		tampered := filepath.Join(t.TempDir(), "tampered.tar")
+		require.NoError(t, writeBundle(tampered, manifest, nil, assetDir))
+
+		err := importer.ImportBundle(tampered, ImportOptions{})
+		assert.ErrorIs(t, err, ErrChecksumMismatch)
+	})
+}
+
+// TestParsePlatform tests parsing of os/arch pairs
+func TestParsePlatform(t *testing.T) {
+	t.Parallel()
+
+	p, err := ParsePlatform("Linux/ARM64")
+	require.NoError(t, err)
+	assert.Equal(t, Platform{OS: "linux", Arch: "arm64"}, p)
+	assert.Equal(t, "linux/arm64", p.String())
+
+	for _, invalid := range []string{"", "linux", "linux/", "/amd64", "linux/arm/v7"} {
+		_, err := ParsePlatform(invalid)
+		assert.Error(t, err, invalid)
+	}
+
+	host := Platform{OS: "linux", Arch: "amd64"}
+	assert.Equal(t, host, host.Override("", ""))
+	assert.Equal(t, Platform{OS: "windows", Arch: "amd64"}, host.Override("Windows", ""))
+	assert.Equal(t, Platform{OS: "linux", Arch: "arm64"}, host.Override("", "arm64"))
+}
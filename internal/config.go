@@ -3,67 +3,255 @@ package grip
 import (
 	"fmt"
 	"os"
+	"os/user"
 	"path/filepath"
 	"runtime"
+	"strings"
 )
 
 // Config holds grip configuration with no global state
 type Config struct {
-	HomeDir     string
-	BinDir      string
-	StorePath   string
-	TempDir     string
+	HomeDir       string // data directory, see resolveLayout
+	BinDir        string
+	ShareDir      string // man pages and shell completions
+	StorePath     string
+	TempDir       string
+	LegacyHomeDir string // ~/.grip of older versions, migrated by MigrateLegacyHome
+
+	// System wide installations, see SystemScope
+	System          bool
+	SystemPrefix    string
+	SystemStorePath string
+
 	OS          string
 	Arch        string
+	Libc        string // C library of Linux hosts, see detectLibc
 	OSAliases   map[string][]string
 	ArchAliases map[string][]string
+
+	// Network settings, see NewHTTPClient
+	HTTPProxy  string
+	HTTPSProxy string
+	NoProxy    string
+	CACerts    []string // extra PEM bundles trusted in addition to the system pool
+	Mirrors    []Mirror
+
+	Token        string       // GitHub API token
+	Concurrency  int          // maximum number of parallel GitHub requests
+	UnpackLimits UnpackLimits // protection against archive bombs
+	ConfigFile   string       // path of the loaded config file
+
+	Packages map[string]PackageSpec // asset selection overrides by lowercase repository path
+}
+
+// Mirror rewrites URLs starting with From to start with To instead
+type Mirror struct {
+	From string
+	To   string
 }
 
 // DefaultConfig creates config with sensible defaults
 func DefaultConfig() (*Config, error) {
-	home, err := os.UserHomeDir()
+	home, err := userHomeDir()
 	if err != nil {
-		return nil, fmt.Errorf("get home directory: %w", err)
+		return nil, err
 	}
 
-	// Handle sudo on Linux
-	if sudoUser := os.Getenv("SUDO_USER"); sudoUser != "" && runtime.GOOS == "linux" {
-		home = filepath.Join("/home", sudoUser)
-	}
+	dirs := resolveLayout(home, os.Getenv)
 
-	gripHome := filepath.Join(home, ".grip")
+	legacyHome := filepath.Join(home, ".grip")
+	if os.Getenv("GRIP_HOME") != "" {
+		legacyHome = ""
+	}
 
 	return &Config{
-		HomeDir:   gripHome,
-		BinDir:    filepath.Join(gripHome, "bin"),
-		StorePath: filepath.Join(gripHome, "grip.json"),
-		TempDir:   os.TempDir(),
-		OS:        runtime.GOOS,
-		Arch:      runtime.GOARCH,
+		HomeDir:         dirs.DataDir,
+		BinDir:          dirs.BinDir,
+		ShareDir:        filepath.Join(dirs.DataDir, "share"),
+		StorePath:       filepath.Join(dirs.StateDir, "grip.json"),
+		TempDir:         dirs.CacheDir,
+		LegacyHomeDir:   legacyHome,
+		SystemPrefix:    "/usr/local",
+		SystemStorePath: "/var/lib/grip/grip.json",
+		OS:              runtime.GOOS,
+		Arch:            runtime.GOARCH,
+		Libc:            detectLibc(),
 		OSAliases: map[string][]string{
-			"darwin": {"macos"},
-			"linux":  {"musl"},
-		},
-		ArchAliases: map[string][]string{
-			"amd64": {"x86_64"},
-			"arm64": {"aarch64", "universal"},
+			"darwin":  {"macos"},
+			"windows": {"win64", "win32", "win"},
 		},
+		ArchAliases:  defaultArchAliases(detectArmLevel()),
+		Concurrency:  4,
+		UnpackLimits: DefaultUnpackLimits(),
+		Packages:     defaultPackages(),
 	}, nil
 }
 
+// LoadConfig creates the config from the defaults, the config file and the
+// environment, in increasing order of precedence
+func LoadConfig() (*Config, error) {
+	cfg, err := DefaultConfig()
+	if err != nil {
+		return nil, err
+	}
+
+	path, err := ConfigFilePath()
+	if err != nil {
+		return nil, err
+	}
+
+	fc, err := ReadConfigFile(path)
+	if err != nil {
+		return nil, err
+	}
+	fc.apply(cfg)
+	cfg.ConfigFile = path
+
+	if err := cfg.applyEnv(); err != nil {
+		return nil, err
+	}
+	return cfg, nil
+}
+
+// applyEnv overrides settings with the environment variables that are set
+func (c *Config) applyEnv() error {
+	if v := getenvAny("HTTP_PROXY", "http_proxy"); v != "" {
+		c.HTTPProxy = v
+	}
+	if v := getenvAny("HTTPS_PROXY", "https_proxy"); v != "" {
+		c.HTTPSProxy = v
+	}
+	if v := getenvAny("NO_PROXY", "no_proxy"); v != "" {
+		c.NoProxy = v
+	}
+	if v := getenvAny("GITHUB_TOKEN", "GH_TOKEN"); v != "" {
+		c.Token = v
+	}
+	c.CACerts = append(c.CACerts, filepath.SplitList(os.Getenv("GRIP_CA_CERTS"))...)
+
+	mirrors, err := ParseMirrors(os.Getenv("GRIP_MIRRORS"))
+	if err != nil {
+		return fmt.Errorf("GRIP_MIRRORS: %w", err)
+	}
+	// Rules from the environment take precedence
+	c.Mirrors = append(mirrors, c.Mirrors...)
+
+	return nil
+}
+
+// userHomeDir returns the home directory of the invoking user, which is
+// the original user when running under sudo
+func userHomeDir() (string, error) {
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return "", fmt.Errorf("get home directory: %w", err)
+	}
+
+	// Under sudo, HOME may point to root's home directory
+	if sudoUser := os.Getenv("SUDO_USER"); sudoUser != "" && runtime.GOOS != "windows" {
+		u, err := user.Lookup(sudoUser)
+		if err != nil {
+			return "", fmt.Errorf("look up sudo user %s: %w", sudoUser, err)
+		}
+		home = u.HomeDir
+	}
+
+	return home, nil
+}
+
+// Platform returns the target platform of this config
+func (c *Config) Platform() Platform {
+	return Platform{OS: c.OS, Arch: c.Arch}
+}
+
+// ForPlatform returns a copy of the config targeting another platform.
+// The libc of another platform is unknown.
+func (c *Config) ForPlatform(p Platform) *Config {
+	clone := *c
+	if p != c.Platform() {
+		clone.Libc = ""
+	}
+	clone.OS = p.OS
+	clone.Arch = p.Arch
+	return &clone
+}
+
+// SystemScope returns a copy of the config for system wide installations:
+// binaries go to SystemPrefix/bin, man pages and completions to
+// SystemPrefix/share, and are recorded in the shared registry
+func (c *Config) SystemScope() *Config {
+	clone := *c
+	clone.System = true
+	clone.HomeDir = filepath.Dir(c.SystemStorePath)
+	clone.BinDir = filepath.Join(c.SystemPrefix, "bin")
+	clone.ShareDir = filepath.Join(c.SystemPrefix, "share")
+	clone.StorePath = c.SystemStorePath
+	clone.TempDir = os.TempDir()
+	clone.LegacyHomeDir = ""
+	return &clone
+}
+
+// ParseMirrors parses a comma separated list of "from=to" rewrite rules
+func ParseMirrors(value string) ([]Mirror, error) {
+	var mirrors []Mirror
+	for _, rule := range strings.Split(value, ",") {
+		rule = strings.TrimSpace(rule)
+		if rule == "" {
+			continue
+		}
+		from, to, ok := strings.Cut(rule, "=")
+		if !ok || from == "" || to == "" {
+			return nil, fmt.Errorf("invalid mirror rule %q, use: from=to", rule)
+		}
+		mirrors = append(mirrors, Mirror{From: from, To: to})
+	}
+	return mirrors, nil
+}
+
+// RewriteURL applies the first matching mirror rule to rawURL
+func (c *Config) RewriteURL(rawURL string) string {
+	for _, m := range c.Mirrors {
+		if strings.HasPrefix(rawURL, m.From) {
+			return m.To + strings.TrimPrefix(rawURL, m.From)
+		}
+	}
+	return rawURL
+}
+
 // EnsureDirs creates necessary directories
 func (c *Config) EnsureDirs() error {
-	return os.MkdirAll(c.BinDir, 0755)
+	for _, dir := range []string{c.HomeDir, c.BinDir, filepath.Dir(c.StorePath), c.TempDir} {
+		if dir == "" {
+			continue
+		}
+		if err := os.MkdirAll(dir, 0755); err != nil {
+			return err
+		}
+	}
+	return nil
 }
 
 // CheckPathEnv checks if BinDir is in PATH
 func (c *Config) CheckPathEnv() bool {
-	pathEnv := os.Getenv("PATH")
-	parts := filepath.SplitList(pathEnv)
-	for _, p := range parts {
-		if p == c.BinDir {
+	return inPath(c.BinDir)
+}
+
+// inPath checks if dir is listed in PATH
+func inPath(dir string) bool {
+	for _, p := range filepath.SplitList(os.Getenv("PATH")) {
+		if filepath.Clean(p) == dir {
 			return true
 		}
 	}
 	return false
 }
+
+// getenvAny returns the value of the first set environment variable
+func getenvAny(keys ...string) string {
+	for _, key := range keys {
+		if value := os.Getenv(key); value != "" {
+			return value
+		}
+	}
+	return ""
+}
@@ -0,0 +1,575 @@
+package grip
+
+import (
+	"errors"
+	"fmt"
+	"net/url"
+	"os"
+	"path/filepath"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/BurntSushi/toml"
+)
+
+// FileConfig is the content of the grip config file. Empty values keep
+// the defaults.
+type FileConfig struct {
+	BinDir      string   `toml:"bin_dir,omitempty"`
+	TempDir     string   `toml:"temp_dir,omitempty"`
+	SysPrefix   string   `toml:"system_prefix,omitempty"`
+	Libc        string   `toml:"libc,omitempty"`
+	Token       string   `toml:"token,omitempty"`
+	Proxy       string   `toml:"proxy,omitempty"`
+	NoProxy     string   `toml:"no_proxy,omitempty"`
+	CACerts     []string `toml:"ca_certs,omitempty"`
+	Concurrency int      `toml:"concurrency,omitempty"`
+
+	UnpackMaxSize    string `toml:"unpack_max_size,omitempty"`
+	UnpackMaxEntries int    `toml:"unpack_max_entries,omitempty"`
+	UnpackMaxRatio   int    `toml:"unpack_max_ratio,omitempty"`
+
+	OSAliases   map[string][]string    `toml:"os_aliases,omitempty"`
+	ArchAliases map[string][]string    `toml:"arch_aliases,omitempty"`
+	Mirrors     map[string]string      `toml:"mirrors,omitempty"`
+	Packages    map[string]PackageSpec `toml:"packages,omitempty"`
+}
+
+// ConfigKeys lists the keys supported by the config file. Keys ending in
+// a dot are tables, addressed as table.name.
+var ConfigKeys = []string{
+	"bin_dir",
+	"temp_dir",
+	"system_prefix",
+	"libc",
+	"token",
+	"proxy",
+	"no_proxy",
+	"ca_certs",
+	"concurrency",
+	"unpack_max_size",
+	"unpack_max_entries",
+	"unpack_max_ratio",
+	"os_aliases.",
+	"arch_aliases.",
+	"mirrors.",
+	"packages.",
+}
+
+// ConfigError reports an invalid config file or key
+type ConfigError struct {
+	Path string
+	Key  string
+	Err  error
+}
+
+func (e *ConfigError) Error() string {
+	if e.Key == "" {
+		return fmt.Sprintf("config %s: %v", e.Path, e.Err)
+	}
+	return fmt.Sprintf("config %s: %s: %v", e.Path, e.Key, e.Err)
+}
+
+func (e *ConfigError) Unwrap() error {
+	return e.Err
+}
+
+// ConfigFilePath returns the path of the config file: $GRIP_CONFIG, or
+// grip/config.toml in $XDG_CONFIG_HOME (default ~/.config)
+func ConfigFilePath() (string, error) {
+	if path := os.Getenv("GRIP_CONFIG"); path != "" {
+		return path, nil
+	}
+
+	dir := os.Getenv("XDG_CONFIG_HOME")
+	if dir == "" {
+		home, err := userHomeDir()
+		if err != nil {
+			return "", err
+		}
+		dir = filepath.Join(home, ".config")
+	}
+
+	return filepath.Join(dir, "grip", "config.toml"), nil
+}
+
+// ReadConfigFile reads and validates the config file at path. A missing
+// file yields an empty config.
+func ReadConfigFile(path string) (*FileConfig, error) {
+	fc := &FileConfig{}
+
+	md, err := toml.DecodeFile(path, fc)
+	if errors.Is(err, os.ErrNotExist) {
+		return fc, nil
+	}
+	if err != nil {
+		var perr toml.ParseError
+		if errors.As(err, &perr) {
+			return nil, &ConfigError{
+				Path: path,
+				Key:  perr.LastKey,
+				Err:  fmt.Errorf("line %d: %s", perr.Position.Line, perr.Message),
+			}
+		}
+		return nil, &ConfigError{Path: path, Err: err}
+	}
+
+	if undecoded := md.Undecoded(); len(undecoded) > 0 {
+		return nil, &ConfigError{Path: path, Key: undecoded[0].String(), Err: errors.New("unknown key")}
+	}
+
+	if err := fc.Validate(); err != nil {
+		var cerr *ConfigError
+		if errors.As(err, &cerr) {
+			cerr.Path = path
+		}
+		return nil, err
+	}
+
+	return fc, nil
+}
+
+// WriteConfigFile validates fc and writes it to path atomically.
+// The file is only readable by the owner since it may contain a token.
+func WriteConfigFile(path string, fc *FileConfig) error {
+	if err := fc.Validate(); err != nil {
+		var cerr *ConfigError
+		if errors.As(err, &cerr) {
+			cerr.Path = path
+		}
+		return err
+	}
+
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return fmt.Errorf("create config directory: %w", err)
+	}
+
+	tmpPath := path + ".tmp"
+	f, err := os.OpenFile(tmpPath, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, 0600)
+	if err != nil {
+		return err
+	}
+
+	if err := toml.NewEncoder(f).Encode(fc); err != nil {
+		f.Close()
+		return err
+	}
+
+	if err := f.Close(); err != nil {
+		return err
+	}
+
+	return os.Rename(tmpPath, path)
+}
+
+// Validate checks all values and reports the first invalid key
+func (fc *FileConfig) Validate() error {
+	invalid := func(key, format string, args ...any) error {
+		return &ConfigError{Key: key, Err: fmt.Errorf(format, args...)}
+	}
+
+	if fc.BinDir != "" && !filepath.IsAbs(expandHome(fc.BinDir)) {
+		return invalid("bin_dir", "%w: %s", ErrNoAbsolutePath, fc.BinDir)
+	}
+	if fc.TempDir != "" && !filepath.IsAbs(expandHome(fc.TempDir)) {
+		return invalid("temp_dir", "%w: %s", ErrNoAbsolutePath, fc.TempDir)
+	}
+	if fc.SysPrefix != "" && !filepath.IsAbs(fc.SysPrefix) {
+		return invalid("system_prefix", "%w: %s", ErrNoAbsolutePath, fc.SysPrefix)
+	}
+	switch fc.Libc {
+	case "", LibcGNU, LibcMusl:
+	default:
+		return invalid("libc", "invalid value %q, valid: %s, %s", fc.Libc, LibcGNU, LibcMusl)
+	}
+
+	if fc.Proxy != "" {
+		if u, err := url.Parse(fc.Proxy); err != nil || u.Scheme == "" || u.Host == "" {
+			return invalid("proxy", "invalid proxy URL %q", fc.Proxy)
+		}
+	}
+
+	for _, path := range fc.CACerts {
+		if path == "" {
+			return invalid("ca_certs", "empty path")
+		}
+	}
+
+	if fc.Concurrency < 0 || fc.Concurrency > 64 {
+		return invalid("concurrency", "must be between 0 (the default of 4) and 64, got %d", fc.Concurrency)
+	}
+
+	if fc.UnpackMaxSize != "" {
+		if _, err := parseSize(fc.UnpackMaxSize); err != nil {
+			return invalid("unpack_max_size", "%w", err)
+		}
+	}
+	if fc.UnpackMaxEntries < 0 {
+		return invalid("unpack_max_entries", "must be positive, got %d", fc.UnpackMaxEntries)
+	}
+	if fc.UnpackMaxRatio < 0 {
+		return invalid("unpack_max_ratio", "must be positive, got %d", fc.UnpackMaxRatio)
+	}
+
+	for _, name := range mapKeys(fc.OSAliases) {
+		if slices.Contains(fc.OSAliases[name], "") {
+			return invalid("os_aliases."+name, "empty alias")
+		}
+	}
+	for _, name := range mapKeys(fc.ArchAliases) {
+		if slices.Contains(fc.ArchAliases[name], "") {
+			return invalid("arch_aliases."+name, "empty alias")
+		}
+	}
+
+	for _, from := range mapKeys(fc.Mirrors) {
+		to := fc.Mirrors[from]
+		if u, err := url.Parse(to); err != nil || u.Scheme == "" || u.Host == "" {
+			return invalid("mirrors."+from, "invalid mirror URL %q", to)
+		}
+	}
+
+	for _, repo := range mapKeys(fc.Packages) {
+		if _, _, err := ParseRepoPath(repo); err != nil {
+			return invalid("packages."+repo, "%w", err)
+		}
+		if err := ValidateAssetPattern(fc.Packages[repo].AssetPattern); err != nil {
+			return invalid("packages."+repo+".asset_pattern", "%w", err)
+		}
+	}
+
+	return nil
+}
+
+// Get returns the value of key formatted as it is accepted by Set
+func (fc *FileConfig) Get(key string) (string, error) {
+	table, name, isTable := strings.Cut(key, ".")
+	if isTable {
+		switch table {
+		case "os_aliases":
+			return strings.Join(fc.OSAliases[name], ","), nil
+		case "arch_aliases":
+			return strings.Join(fc.ArchAliases[name], ","), nil
+		case "mirrors":
+			return fc.Mirrors[name], nil
+		case "packages":
+			repo, field, err := cutPackageKey(key, name)
+			if err != nil {
+				return "", err
+			}
+			if field == "binary" {
+				return fc.Packages[repo].Binary, nil
+			}
+			return fc.Packages[repo].AssetPattern, nil
+		}
+		return "", &ConfigError{Key: key, Err: errors.New("unknown key")}
+	}
+
+	switch key {
+	case "bin_dir":
+		return fc.BinDir, nil
+	case "temp_dir":
+		return fc.TempDir, nil
+	case "system_prefix":
+		return fc.SysPrefix, nil
+	case "libc":
+		return fc.Libc, nil
+	case "token":
+		return fc.Token, nil
+	case "proxy":
+		return fc.Proxy, nil
+	case "no_proxy":
+		return fc.NoProxy, nil
+	case "ca_certs":
+		return strings.Join(fc.CACerts, ","), nil
+	case "concurrency":
+		return formatCount(fc.Concurrency), nil
+	case "unpack_max_size":
+		return fc.UnpackMaxSize, nil
+	case "unpack_max_entries":
+		return formatCount(fc.UnpackMaxEntries), nil
+	case "unpack_max_ratio":
+		return formatCount(fc.UnpackMaxRatio), nil
+	}
+	return "", &ConfigError{Key: key, Err: errors.New("unknown key")}
+}
+
+// Set parses value and assigns it to key. Lists are comma separated and
+// an empty value removes the key.
+func (fc *FileConfig) Set(key, value string) error {
+	value = strings.TrimSpace(value)
+
+	table, name, isTable := strings.Cut(key, ".")
+	if isTable {
+		if name == "" {
+			return &ConfigError{Key: key, Err: errors.New("missing name after table")}
+		}
+		switch table {
+		case "os_aliases":
+			fc.OSAliases = setListEntry(fc.OSAliases, name, value)
+		case "arch_aliases":
+			fc.ArchAliases = setListEntry(fc.ArchAliases, name, value)
+		case "mirrors":
+			if value == "" {
+				delete(fc.Mirrors, name)
+				break
+			}
+			if fc.Mirrors == nil {
+				fc.Mirrors = make(map[string]string)
+			}
+			fc.Mirrors[name] = value
+		case "packages":
+			repo, field, err := cutPackageKey(key, name)
+			if err != nil {
+				return err
+			}
+			spec := fc.Packages[repo]
+			if field == "binary" {
+				spec.Binary = value
+			} else {
+				spec.AssetPattern = value
+			}
+			if spec == (PackageSpec{}) {
+				delete(fc.Packages, repo)
+				break
+			}
+			if fc.Packages == nil {
+				fc.Packages = make(map[string]PackageSpec)
+			}
+			fc.Packages[repo] = spec
+		default:
+			return &ConfigError{Key: key, Err: errors.New("unknown key")}
+		}
+		return fc.Validate()
+	}
+
+	switch key {
+	case "bin_dir":
+		fc.BinDir = value
+	case "temp_dir":
+		fc.TempDir = value
+	case "system_prefix":
+		fc.SysPrefix = value
+	case "libc":
+		fc.Libc = value
+	case "token":
+		fc.Token = value
+	case "proxy":
+		fc.Proxy = value
+	case "no_proxy":
+		fc.NoProxy = value
+	case "ca_certs":
+		fc.CACerts = splitList(value)
+	case "concurrency":
+		return fc.setCount(key, value, &fc.Concurrency)
+	case "unpack_max_size":
+		fc.UnpackMaxSize = value
+	case "unpack_max_entries":
+		return fc.setCount(key, value, &fc.UnpackMaxEntries)
+	case "unpack_max_ratio":
+		return fc.setCount(key, value, &fc.UnpackMaxRatio)
+	default:
+		return &ConfigError{Key: key, Err: errors.New("unknown key")}
+	}
+	return fc.Validate()
+}
+
+// setCount parses the number value of key into n, an empty value unsets it
+func (fc *FileConfig) setCount(key, value string, n *int) error {
+	if value == "" {
+		*n = 0
+		return fc.Validate()
+	}
+	v, err := strconv.Atoi(value)
+	if err != nil {
+		return &ConfigError{Key: key, Err: fmt.Errorf("not a number: %q", value)}
+	}
+	*n = v
+	return fc.Validate()
+}
+
+// formatCount formats a number setting, "" if it's unset
+func formatCount(n int) string {
+	if n == 0 {
+		return ""
+	}
+	return strconv.Itoa(n)
+}
+
+// Keys returns all keys that are set, sorted
+func (fc *FileConfig) Keys() []string {
+	var keys []string
+	for _, key := range ConfigKeys {
+		if table, ok := strings.CutSuffix(key, "."); ok {
+			var names []string
+			switch table {
+			case "os_aliases":
+				names = mapKeys(fc.OSAliases)
+			case "arch_aliases":
+				names = mapKeys(fc.ArchAliases)
+			case "mirrors":
+				names = mapKeys(fc.Mirrors)
+			case "packages":
+				for _, repo := range mapKeys(fc.Packages) {
+					if fc.Packages[repo].AssetPattern != "" {
+						names = append(names, repo+".asset_pattern")
+					}
+					if fc.Packages[repo].Binary != "" {
+						names = append(names, repo+".binary")
+					}
+				}
+			}
+			for _, name := range names {
+				keys = append(keys, key+name)
+			}
+			continue
+		}
+		if value, _ := fc.Get(key); value != "" {
+			keys = append(keys, key)
+		}
+	}
+	return keys
+}
+
+// apply overrides cfg with all values that are set
+func (fc *FileConfig) apply(cfg *Config) {
+	if fc.BinDir != "" {
+		cfg.BinDir = expandHome(fc.BinDir)
+	}
+	if fc.TempDir != "" {
+		cfg.TempDir = expandHome(fc.TempDir)
+	}
+	if fc.SysPrefix != "" {
+		cfg.SystemPrefix = fc.SysPrefix
+	}
+	if fc.Libc != "" && cfg.OS == "linux" {
+		cfg.Libc = fc.Libc
+	}
+	if fc.Token != "" {
+		cfg.Token = fc.Token
+	}
+	if fc.Proxy != "" {
+		cfg.HTTPProxy = fc.Proxy
+		cfg.HTTPSProxy = fc.Proxy
+	}
+	if fc.NoProxy != "" {
+		cfg.NoProxy = fc.NoProxy
+	}
+	for _, path := range fc.CACerts {
+		cfg.CACerts = append(cfg.CACerts, expandHome(path))
+	}
+	if fc.Concurrency > 0 {
+		cfg.Concurrency = fc.Concurrency
+	}
+	if size, err := parseSize(fc.UnpackMaxSize); err == nil && size > 0 {
+		cfg.UnpackLimits.MaxSize = size
+	}
+	if fc.UnpackMaxEntries > 0 {
+		cfg.UnpackLimits.MaxEntries = fc.UnpackMaxEntries
+	}
+	if fc.UnpackMaxRatio > 0 {
+		cfg.UnpackLimits.MaxRatio = fc.UnpackMaxRatio
+	}
+	for name, aliases := range fc.OSAliases {
+		cfg.OSAliases[name] = aliases
+	}
+	for name, aliases := range fc.ArchAliases {
+		cfg.ArchAliases[name] = aliases
+	}
+
+	if cfg.Packages == nil && len(fc.Packages) > 0 {
+		cfg.Packages = make(map[string]PackageSpec)
+	}
+	for repo, spec := range fc.Packages {
+		cfg.Packages[packageKey(repo)] = spec
+	}
+
+	// Longer prefixes first so that the most specific rule wins
+	froms := mapKeys(fc.Mirrors)
+	sort.SliceStable(froms, func(i, j int) bool { return len(froms[i]) > len(froms[j]) })
+	for _, from := range froms {
+		cfg.Mirrors = append(cfg.Mirrors, Mirror{From: from, To: fc.Mirrors[from]})
+	}
+}
+
+// cutPackageKey splits the name of a packages.<repo>.<field> key
+func cutPackageKey(key, name string) (repo, field string, err error) {
+	i := strings.LastIndex(name, ".")
+	if i <= 0 {
+		return "", "", &ConfigError{Key: key, Err: errors.New("use packages.<repo>.asset_pattern or packages.<repo>.binary")}
+	}
+	repo, field = name[:i], name[i+1:]
+	if field != "asset_pattern" && field != "binary" {
+		return "", "", &ConfigError{Key: key, Err: errors.New("unknown key")}
+	}
+	return repo, field, nil
+}
+
+// parseSize parses a size in bytes with an optional binary unit, e.g. 512M
+// or 4GiB
+func parseSize(value string) (int64, error) {
+	units := []struct {
+		suffix string
+		factor int64
+	}{{"K", 1 << 10}, {"M", 1 << 20}, {"G", 1 << 30}, {"T", 1 << 40}}
+
+	number := strings.TrimSuffix(strings.TrimSuffix(strings.ToUpper(strings.TrimSpace(value)), "B"), "I")
+	factor := int64(1)
+	for _, u := range units {
+		if n, ok := strings.CutSuffix(number, u.suffix); ok {
+			number, factor = n, u.factor
+			break
+		}
+	}
+	n, err := strconv.ParseInt(strings.TrimSpace(number), 10, 64)
+	if err != nil || n <= 0 {
+		return 0, fmt.Errorf("invalid size %q, use e.g. 512M or 4GiB", value)
+	}
+	return n * factor, nil
+}
+
+// expandHome replaces a leading ~ with the home directory of the user
+func expandHome(path string) string {
+	if path != "~" && !strings.HasPrefix(path, "~/") {
+		return path
+	}
+	home, err := userHomeDir()
+	if err != nil {
+		return path
+	}
+	return filepath.Join(home, strings.TrimPrefix(path, "~"))
+}
+
+func setListEntry(m map[string][]string, name, value string) map[string][]string {
+	if value == "" {
+		delete(m, name)
+		return m
+	}
+	if m == nil {
+		m = make(map[string][]string)
+	}
+	m[name] = splitList(value)
+	return m
+}
+
+// splitList splits a comma separated list, dropping empty elements
+func splitList(value string) []string {
+	var list []string
+	for _, v := range strings.Split(value, ",") {
+		if v = strings.TrimSpace(v); v != "" {
+			list = append(list, v)
+		}
+	}
+	return list
+}
+
+func mapKeys[V any](m map[string]V) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
@@ -2,42 +2,186 @@ package grip
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"io"
+	"net"
 	"net/http"
 	"os"
 	"path/filepath"
+	"strconv"
+	"strings"
+	"syscall"
+	"time"
+
+	"github.com/alexjoedt/grip/internal/logger"
+	"github.com/schollz/progressbar/v3"
 )
 
+// errIdleTimeout is the cancel cause used when a download stalls.
+var errIdleTimeout = errors.New("no data received within idle timeout")
+
+// downloadPolicy controls retries and timeouts of a download.
+type downloadPolicy struct {
+	MaxAttempts int           // total number of attempts, including the first
+	Backoff     time.Duration // delay before the first retry, doubled afterwards
+	IdleTimeout time.Duration // abort an attempt when no data arrives for this long
+	Steps       []string      // installation steps for the progress bar, archiveSteps if nil
+}
+
+var defaultDownloadPolicy = downloadPolicy{
+	MaxAttempts: 5,
+	Backoff:     time.Second,
+	IdleTimeout: 30 * time.Second,
+}
+
 // Download downloads a file from the given URL into destDir/filename.
+// Transient failures are retried with exponential backoff. When the server
+// advertises byte range support, a retry resumes the partial file instead of
+// starting over.
 func Download(ctx context.Context, client *http.Client, url, destDir, filename string) error {
+	return download(ctx, client, url, destDir, filename, defaultDownloadPolicy)
+}
+
+func download(ctx context.Context, client *http.Client, url, destDir, filename string, policy downloadPolicy) error {
 	if client == nil {
 		client = &http.Client{}
 	}
 
-	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err := os.MkdirAll(destDir, 0755); err != nil {
+		return fmt.Errorf("create download directory: %w", err)
+	}
+
+	steps := policy.Steps
+	if steps == nil {
+		steps = archiveSteps
+	}
+
+	fullPath := filepath.Join(destDir, filename)
+	state := &downloadState{
+		url:   url,
+		path:  fullPath + ".part",
+		total: -1,
+		label: stepLabel(steps, stepDownload),
+	}
+	defer state.close()
+
+	for attempt := 1; ; attempt++ {
+		err := state.fetch(ctx, client, policy.IdleTimeout)
+		if err == nil {
+			break
+		}
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+		if !isRetryable(err) || attempt >= policy.MaxAttempts {
+			return err
+		}
+
+		delay := policy.Backoff << (attempt - 1)
+		logger.Info("Download failed: %v, retrying in %s (attempt %d/%d)", err, delay, attempt+1, policy.MaxAttempts)
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(delay):
+		}
+	}
+
+	if err := os.Rename(state.path, fullPath); err != nil {
+		return fmt.Errorf("finalize download: %w", err)
+	}
+	return nil
+}
+
+// downloadState carries what was learned about the remote file across attempts.
+type downloadState struct {
+	url          string
+	path         string
+	total        int64 // expected size, -1 if unknown
+	acceptRanges bool
+	validator    string // ETag or Last-Modified used for If-Range
+	label        string // progress bar description
+	bar          *progressbar.ProgressBar
+}
+
+// fetch performs a single download attempt, resuming the partial file when possible.
+func (s *downloadState) fetch(ctx context.Context, client *http.Client, idleTimeout time.Duration) error {
+	offset := int64(0)
+	if info, err := os.Stat(s.path); err == nil {
+		offset = info.Size()
+	}
+	if offset > 0 && !s.acceptRanges {
+		offset = 0
+	}
+
+	reqCtx, cancel := context.WithCancelCause(ctx)
+	defer cancel(nil)
+
+	req, err := http.NewRequestWithContext(reqCtx, http.MethodGet, s.url, nil)
 	if err != nil {
 		return fmt.Errorf("create request: %w", err)
 	}
+	if offset > 0 {
+		req.Header.Set("Range", fmt.Sprintf("bytes=%d-", offset))
+		if s.validator != "" {
+			req.Header.Set("If-Range", s.validator)
+		}
+	}
+
+	var idle *time.Timer
+	if idleTimeout > 0 {
+		idle = time.AfterFunc(idleTimeout, func() { cancel(errIdleTimeout) })
+		defer idle.Stop()
+	}
 
 	res, err := client.Do(req)
 	if err != nil {
-		return fmt.Errorf("download file: %w", err)
+		return classifyError(reqCtx, fmt.Errorf("download file: %w", err))
 	}
 	defer func() {
 		_ = res.Body.Close()
 	}()
 
-	if res.StatusCode > 299 {
-		return fmt.Errorf("download failed with status %s", res.Status)
+	switch {
+	case res.StatusCode == http.StatusPartialContent && offset > 0:
+		start, total, ok := parseContentRange(res.Header.Get("Content-Range"))
+		if !ok || start != offset {
+			_ = os.Remove(s.path)
+			return retryable(fmt.Errorf("unexpected content range %q", res.Header.Get("Content-Range")))
+		}
+		if total >= 0 {
+			s.total = total
+		}
+		logger.Info("Resuming download at %d bytes", offset)
+	case res.StatusCode == http.StatusRequestedRangeNotSatisfiable && offset > 0:
+		// Either the file is already complete or it changed on the server
+		if s.total == offset {
+			return nil
+		}
+		_ = os.Remove(s.path)
+		return retryable(fmt.Errorf("download failed with status %s", res.Status))
+	case res.StatusCode > 299:
+		err := fmt.Errorf("download failed with status %s", res.Status)
+		if res.StatusCode >= 500 || res.StatusCode == http.StatusTooManyRequests || res.StatusCode == http.StatusRequestTimeout {
+			return retryable(err)
+		}
+		return err
+	default:
+		// Full body: the server ignored or refused the range
+		offset = 0
+		s.total = res.ContentLength
+		s.acceptRanges = strings.EqualFold(res.Header.Get("Accept-Ranges"), "bytes")
+		s.validator = res.Header.Get("ETag")
+		if s.validator == "" {
+			s.validator = res.Header.Get("Last-Modified")
+		}
 	}
 
-	if err := os.MkdirAll(destDir, 0755); err != nil {
-		return fmt.Errorf("create download directory: %w", err)
+	flags := os.O_CREATE | os.O_WRONLY | os.O_TRUNC
+	if offset > 0 {
+		flags = os.O_CREATE | os.O_WRONLY | os.O_APPEND
 	}
-
-	fullPath := filepath.Join(destDir, filename)
-	f, err := os.Create(fullPath)
+	f, err := os.OpenFile(s.path, flags, 0644)
 	if err != nil {
 		return fmt.Errorf("create file: %w", err)
 	}
@@ -45,11 +189,114 @@ func Download(ctx context.Context, client *http.Client, url, destDir, filename s
 		_ = f.Close()
 	}()
 
-	bar := NewProgressBar(int(res.ContentLength), "[cyan][1/3][reset] Downloading")
-	if _, err = io.Copy(io.MultiWriter(f, bar), res.Body); err != nil {
-		return fmt.Errorf("write file: %w", err)
+	if s.bar == nil {
+		s.bar = NewProgressBar(int(s.total), s.label)
+	}
+	if s.bar.GetMax64() != s.total {
+		s.bar.ChangeMax64(s.total)
+	}
+	_ = s.bar.Set64(offset)
+
+	var body io.Reader = res.Body
+	if idle != nil {
+		body = &idleReader{r: res.Body, timer: idle, timeout: idleTimeout}
 	}
 
-	fmt.Println() // new line after progress bar
+	written, err := io.Copy(io.MultiWriter(f, s.bar), body)
+	if err != nil {
+		return classifyError(reqCtx, fmt.Errorf("write file: %w", err))
+	}
+
+	if s.total >= 0 && offset+written != s.total {
+		return retryable(fmt.Errorf("%w: got %d of %d bytes", ErrIncompleteDownload, offset+written, s.total))
+	}
 	return nil
 }
+
+// close terminates the progress bar line, if one was drawn.
+func (s *downloadState) close() {
+	if s.bar != nil {
+		fmt.Println() // new line after progress bar
+	}
+}
+
+// idleReader resets the idle timer whenever data arrives.
+type idleReader struct {
+	r       io.Reader
+	timer   *time.Timer
+	timeout time.Duration
+}
+
+func (r *idleReader) Read(p []byte) (int, error) {
+	n, err := r.r.Read(p)
+	if n > 0 {
+		r.timer.Reset(r.timeout)
+	}
+	return n, err
+}
+
+// parseContentRange parses a "bytes start-end/total" header value.
+// total is -1 when the server reports it as unknown ("*").
+func parseContentRange(value string) (start, total int64, ok bool) {
+	spec, found := strings.CutPrefix(value, "bytes ")
+	if !found {
+		return 0, 0, false
+	}
+	rng, size, found := strings.Cut(spec, "/")
+	if !found {
+		return 0, 0, false
+	}
+	first, _, found := strings.Cut(rng, "-")
+	if !found {
+		return 0, 0, false
+	}
+
+	start, err := strconv.ParseInt(first, 10, 64)
+	if err != nil {
+		return 0, 0, false
+	}
+	total = -1
+	if size != "*" {
+		if total, err = strconv.ParseInt(size, 10, 64); err != nil {
+			return 0, 0, false
+		}
+	}
+	return start, total, true
+}
+
+// retryableError marks an error as transient.
+type retryableError struct {
+	err error
+}
+
+func (e *retryableError) Error() string { return e.err.Error() }
+func (e *retryableError) Unwrap() error { return e.err }
+
+func retryable(err error) error {
+	return &retryableError{err: err}
+}
+
+func isRetryable(err error) bool {
+	var re *retryableError
+	return errors.As(err, &re)
+}
+
+// classifyError marks connection resets, unexpected EOFs, timeouts and
+// idle stalls as retryable. Cancellation of the parent context is not.
+func classifyError(reqCtx context.Context, err error) error {
+	if errors.Is(context.Cause(reqCtx), errIdleTimeout) {
+		return retryable(fmt.Errorf("%w: %w", errIdleTimeout, err))
+	}
+
+	var netErr net.Error
+	switch {
+	case errors.Is(err, io.ErrUnexpectedEOF),
+		errors.Is(err, io.EOF),
+		errors.Is(err, syscall.ECONNRESET),
+		errors.Is(err, syscall.ECONNABORTED),
+		errors.Is(err, syscall.EPIPE),
+		errors.As(err, &netErr) && netErr.Timeout():
+		return retryable(err)
+	}
+	return err
+}
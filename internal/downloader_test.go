@@ -0,0 +1,184 @@
+package grip
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strconv"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// fastDownloadPolicy keeps retry tests quick.
+var fastDownloadPolicy = downloadPolicy{
+	MaxAttempts: 3,
+	Backoff:     time.Millisecond,
+	IdleTimeout: 200 * time.Millisecond,
+}
+
+// TestDownloadRetry exercises retries and resumption against real HTTP servers.
+func TestDownloadRetry(t *testing.T) {
+	t.Parallel()
+
+	content := bytes.Repeat([]byte("0123456789abcdef"), 4096)
+	modTime := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+
+	t.Run("resumes after dropped connection", func(t *testing.T) {
+		t.Parallel()
+
+		var calls atomic.Int32
+		var ranges []string
+		var mu sync.Mutex
+		srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			mu.Lock()
+			ranges = append(ranges, r.Header.Get("Range"))
+			mu.Unlock()
+
+			w.Header().Set("ETag", `"v1"`)
+			if calls.Add(1) == 1 {
+				// Announce the full length, send half of it and drop the connection
+				w.Header().Set("Accept-Ranges", "bytes")
+				w.Header().Set("Content-Length", strconv.Itoa(len(content)))
+				w.WriteHeader(http.StatusOK)
+				_, _ = w.Write(content[:len(content)/2])
+				w.(http.Flusher).Flush()
+				conn, _, err := w.(http.Hijacker).Hijack()
+				if !assert.NoError(t, err) {
+					return
+				}
+				_ = conn.Close()
+				return
+			}
+			http.ServeContent(w, r, "asset.bin", modTime, bytes.NewReader(content))
+		}))
+		defer srv.Close()
+
+		destDir := t.TempDir()
+		require.NoError(t, download(context.Background(), srv.Client(), srv.URL, destDir, "asset.bin", fastDownloadPolicy))
+
+		got, err := os.ReadFile(filepath.Join(destDir, "asset.bin"))
+		require.NoError(t, err)
+		assert.Equal(t, content, got)
+		assert.Equal(t, int32(2), calls.Load())
+		assert.Equal(t, []string{"", fmt.Sprintf("bytes=%d-", len(content)/2)}, ranges)
+		assert.NoFileExists(t, filepath.Join(destDir, "asset.bin.part"))
+	})
+
+	t.Run("restarts when range is not supported", func(t *testing.T) {
+		t.Parallel()
+
+		var calls atomic.Int32
+		srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			assert.Empty(t, r.Header.Get("Range"))
+			w.Header().Set("Content-Length", strconv.Itoa(len(content)))
+			w.WriteHeader(http.StatusOK)
+			if calls.Add(1) == 1 {
+				_, _ = w.Write(content[:100])
+				w.(http.Flusher).Flush()
+				conn, _, err := w.(http.Hijacker).Hijack()
+				if !assert.NoError(t, err) {
+					return
+				}
+				_ = conn.Close()
+				return
+			}
+			_, _ = w.Write(content)
+		}))
+		defer srv.Close()
+
+		destDir := t.TempDir()
+		require.NoError(t, download(context.Background(), srv.Client(), srv.URL, destDir, "asset.bin", fastDownloadPolicy))
+
+		got, err := os.ReadFile(filepath.Join(destDir, "asset.bin"))
+		require.NoError(t, err)
+		assert.Equal(t, content, got)
+	})
+
+	t.Run("retries server errors", func(t *testing.T) {
+		t.Parallel()
+
+		var calls atomic.Int32
+		srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if calls.Add(1) < 3 {
+				w.WriteHeader(http.StatusServiceUnavailable)
+				return
+			}
+			http.ServeContent(w, r, "asset.bin", modTime, bytes.NewReader(content))
+		}))
+		defer srv.Close()
+
+		destDir := t.TempDir()
+		require.NoError(t, download(context.Background(), srv.Client(), srv.URL, destDir, "asset.bin", fastDownloadPolicy))
+		assert.Equal(t, int32(3), calls.Load())
+	})
+
+	t.Run("gives up after max attempts", func(t *testing.T) {
+		t.Parallel()
+
+		var calls atomic.Int32
+		srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			calls.Add(1)
+			w.WriteHeader(http.StatusBadGateway)
+		}))
+		defer srv.Close()
+
+		err := download(context.Background(), srv.Client(), srv.URL, t.TempDir(), "asset.bin", fastDownloadPolicy)
+		assert.ErrorContains(t, err, "download failed with status")
+		assert.Equal(t, int32(fastDownloadPolicy.MaxAttempts), calls.Load())
+	})
+
+	t.Run("does not retry client errors", func(t *testing.T) {
+		t.Parallel()
+
+		var calls atomic.Int32
+		srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			calls.Add(1)
+			w.WriteHeader(http.StatusNotFound)
+		}))
+		defer srv.Close()
+
+		err := download(context.Background(), srv.Client(), srv.URL, t.TempDir(), "asset.bin", fastDownloadPolicy)
+		assert.Error(t, err)
+		assert.Equal(t, int32(1), calls.Load())
+	})
+
+	t.Run("retries stalled transfer", func(t *testing.T) {
+		t.Parallel()
+
+		var calls atomic.Int32
+		release := make(chan struct{})
+		defer close(release)
+		srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if calls.Add(1) == 1 {
+				w.Header().Set("Accept-Ranges", "bytes")
+				w.Header().Set("Content-Length", strconv.Itoa(len(content)))
+				w.WriteHeader(http.StatusOK)
+				_, _ = w.Write(content[:1024])
+				w.(http.Flusher).Flush()
+				select {
+				case <-release:
+				case <-r.Context().Done():
+				}
+				return
+			}
+			http.ServeContent(w, r, "asset.bin", modTime, bytes.NewReader(content))
+		}))
+		defer srv.Close()
+
+		destDir := t.TempDir()
+		require.NoError(t, download(context.Background(), srv.Client(), srv.URL, destDir, "asset.bin", fastDownloadPolicy))
+
+		got, err := os.ReadFile(filepath.Join(destDir, "asset.bin"))
+		require.NoError(t, err)
+		assert.Equal(t, content, got)
+	})
+}
@@ -9,4 +9,16 @@ var (
 	ErrInvalidRepo    error = errors.New("invalid repository path")
 	ErrNotFound       error = errors.New("not found")
 	ErrAlreadyExists  error = errors.New("already exists")
+	ErrNotRoot        error = errors.New("system scope requires root, run with sudo")
+	ErrWrongPlatform  error = errors.New("binary built for another platform")
+	ErrCrossTarget    error = errors.New("installing for another platform requires a destination")
+	ErrNoExecutable   error = errors.New("no executable found in archive")
+	ErrAmbiguousBin   error = errors.New("several executables found in archive")
+
+	ErrUnsupportedFormat error = errors.New("unsupported archive format")
+	ErrUnsafePath        error = errors.New("path traversal attempt")
+	ErrUnpackLimit       error = errors.New("archive exceeds unpack limit")
+
+	ErrIncompleteDownload error = errors.New("incomplete download")
+	ErrChecksumMismatch   error = errors.New("checksum mismatch")
 )
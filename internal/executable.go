@@ -0,0 +1,243 @@
+package grip
+
+import (
+	"cmp"
+	"debug/pe"
+	"fmt"
+	"io"
+	"os"
+	"path"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	"github.com/alexjoedt/grip/internal/logger"
+)
+
+// executableTypes are the MIME types of executable binaries, Windows
+// executables are told apart from DLLs by isWindowsExecutable
+var executableTypes = map[string]bool{
+	"application/x-mach-binary": true,
+	"application/x-executable":  true,
+}
+
+// Scores of executables in an archive, see rankExecutables
+const (
+	scoreExeName    = 100 // named like the repository or alias
+	scoreExePrefix  = 50  // name starts with it, e.g. tool-linux-amd64
+	scoreExeBit     = 10  // executable bit set in the archive
+	scoreExeScript  = -5  // scripts rank below binaries
+	scoreExeDepth   = -2  // per directory level
+	scoreExeFixture = -30 // below test, example or fixture directories
+)
+
+// fixtureDirs are directories holding test fixtures and examples rather
+// than the executable of a release
+var fixtureDirs = []string{"test", "tests", "testdata", "fixture", "fixtures", "example", "examples", "bench", "benchmarks"}
+
+// exeCandidate is an executable found in an unpacked archive
+type exeCandidate struct {
+	path   string
+	rel    string // slash separated path in the archive
+	size   int64
+	exeBit bool
+	script bool
+	link   bool // symlink to an executable in the archive
+	score  int
+}
+
+// findCandidates returns the executable binaries and the scripts starting
+// with #! in the directory tree, in lexical order. Symlinks to them are
+// candidates too, e.g. bin/tool linking to a versioned executable.
+func findCandidates(dir string) (bins, scripts []exeCandidate, err error) {
+	err = filepath.WalkDir(dir, func(p string, d os.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		link := d.Type()&os.ModeSymlink != 0
+		if !link && !d.Type().IsRegular() {
+			return nil
+		}
+		// Symlinks were checked to stay inside the directory when unpacking
+		info, err := os.Stat(p)
+		if err != nil || !info.Mode().IsRegular() {
+			return nil
+		}
+		rel, err := filepath.Rel(dir, p)
+		if err != nil {
+			return err
+		}
+		c := exeCandidate{path: p, rel: filepath.ToSlash(rel), size: info.Size(), exeBit: info.Mode()&0111 != 0, link: link}
+
+		if isExecutableBinary(p) {
+			bins = append(bins, c)
+		} else if isScript(p) {
+			c.script = true
+			scripts = append(scripts, c)
+		}
+		return nil
+	})
+	return bins, scripts, err
+}
+
+// isExecutableBinary reports whether the file at path is an executable binary
+func isExecutableBinary(path string) bool {
+	mimeType, err := detectFileType(path)
+	return err == nil && executableTypes[mimeType] || isWindowsExecutable(path)
+}
+
+// isWindowsExecutable reports whether the file at path is a PE executable,
+// but no DLL
+func isWindowsExecutable(path string) bool {
+	f, err := pe.Open(path)
+	if err != nil {
+		return false
+	}
+	defer f.Close()
+	return f.Characteristics&pe.IMAGE_FILE_DLL == 0
+}
+
+// isScript reports whether the file at path starts with #!
+func isScript(path string) bool {
+	f, err := os.Open(path)
+	if err != nil {
+		return false
+	}
+	defer f.Close()
+	shebang := make([]byte, 2)
+	_, err = io.ReadFull(f, shebang)
+	return err == nil && string(shebang) == "#!"
+}
+
+// findExecutables returns all executable binaries in the directory tree, in
+// lexical order, and with scripts the #! scripts too. Of a symlink and the
+// executable it links to, only the symlink is returned.
+func findExecutables(dir string, scripts bool) ([]string, error) {
+	bins, scr, err := findCandidates(dir)
+	if err != nil {
+		return nil, err
+	}
+	if scripts {
+		bins = append(bins, scr...)
+	}
+
+	var paths []string
+	for _, c := range dedupeLinks(bins) {
+		paths = append(paths, c.path)
+	}
+	slices.Sort(paths)
+	return paths, nil
+}
+
+// dedupeLinks drops executables that a symlink among the candidates links to
+func dedupeLinks(candidates []exeCandidate) []exeCandidate {
+	linked := make(map[string]bool)
+	for _, c := range candidates {
+		if c.link {
+			if target, err := filepath.EvalSymlinks(c.path); err == nil {
+				linked[target] = true
+			}
+		}
+	}
+	return slices.DeleteFunc(candidates, func(c exeCandidate) bool {
+		if c.link {
+			return false
+		}
+		real, err := filepath.EvalSymlinks(c.path)
+		return err == nil && linked[real]
+	})
+}
+
+// findExecutable returns the executable in the directory tree that most
+// likely is the one of the release, see rankExecutables. Scripts are
+// candidates only with scripts. names are the repository name and alias.
+func findExecutable(dir string, names []string, scripts bool) (string, error) {
+	bins, scr, err := findCandidates(dir)
+	if err != nil {
+		return "", err
+	}
+	candidates := bins
+	if scripts {
+		candidates = append(candidates, scr...)
+	}
+	candidates = dedupeLinks(candidates)
+
+	if len(candidates) == 0 {
+		if len(scr) > 0 {
+			return "", fmt.Errorf("%w, only scripts: %s; use --scripts to install one", ErrNoExecutable, candidatePaths(scr))
+		}
+		return "", ErrNoExecutable
+	}
+
+	rankExecutables(candidates, names)
+	if len(candidates) > 1 && !clearWinner(candidates[0], candidates[1]) {
+		return "", fmt.Errorf("%w: %s; use --binary to choose one", ErrAmbiguousBin, candidatePaths(candidates))
+	}
+	if len(candidates) > 1 {
+		logger.Info("Choosing %s of %d executables: %s", candidates[0].rel, len(candidates), candidatePaths(candidates))
+	}
+	return candidates[0].path, nil
+}
+
+// rankExecutables scores the candidates by their name matching one of names,
+// the executable bit set in the archive, their depth and whether they are
+// scripts or fixtures, and sorts them by score and size, best first
+func rankExecutables(candidates []exeCandidate, names []string) {
+	for n := range candidates {
+		c := &candidates[n]
+		c.score = exeNameScore(path.Base(c.rel), names)
+		if c.exeBit {
+			c.score += scoreExeBit
+		}
+		if c.script {
+			c.score += scoreExeScript
+		}
+		dirs := strings.Split(path.Dir(c.rel), "/")
+		if path.Dir(c.rel) != "." {
+			c.score += scoreExeDepth * len(dirs)
+		}
+		for _, dir := range dirs {
+			if slices.Contains(fixtureDirs, strings.ToLower(dir)) {
+				c.score += scoreExeFixture
+				break
+			}
+		}
+	}
+	slices.SortStableFunc(candidates, func(a, b exeCandidate) int {
+		return cmp.Or(cmp.Compare(b.score, a.score), cmp.Compare(b.size, a.size), strings.Compare(a.rel, b.rel))
+	})
+}
+
+// exeNameScore scores how well the file name base matches one of names
+func exeNameScore(base string, names []string) int {
+	base = strings.ToLower(base)
+	trimmed := strings.TrimSuffix(base, path.Ext(base))
+	score := 0
+	for _, name := range names {
+		name = strings.ToLower(name)
+		switch {
+		case name == "":
+		case base == name || trimmed == name:
+			return scoreExeName
+		case len(base) > len(name) && strings.HasPrefix(base, name) && strings.ContainsRune("-_.", rune(base[len(name)])):
+			score = scoreExePrefix
+		}
+	}
+	return score
+}
+
+// clearWinner reports whether the best ranked candidate a is obviously the
+// executable of the release compared to the runner-up b: it scores higher,
+// or it's at least twice as large, as helpers are usually small
+func clearWinner(a, b exeCandidate) bool {
+	return a.score > b.score || a.size >= 2*b.size
+}
+
+// candidatePaths lists the archive paths of the candidates
+func candidatePaths(candidates []exeCandidate) string {
+	var paths []string
+	for _, c := range candidates {
+		paths = append(paths, c.rel)
+	}
+	return strings.Join(paths, ", ")
+}
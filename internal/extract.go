@@ -0,0 +1,237 @@
+package grip
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/schollz/progressbar/v3"
+)
+
+// UnpackLimits bound what unpacking an archive may extract, protecting
+// against archive bombs. Zero disables a limit.
+type UnpackLimits struct {
+	MaxSize    int64 // total size of the extracted files in bytes
+	MaxEntries int   // number of extracted files, directories and links
+	MaxRatio   int   // extracted bytes per byte of the archive
+}
+
+// DefaultUnpackLimits returns the limits used unless configured otherwise
+func DefaultUnpackLimits() UnpackLimits {
+	return UnpackLimits{MaxSize: 4 << 30, MaxEntries: 100_000, MaxRatio: 100}
+}
+
+// ratioGrace is the extracted size up to which the compression ratio isn't
+// checked, as small files like padded test data compress extremely well
+const ratioGrace = 1 << 20
+
+// maxLinkTarget is the maximum length of a symlink target stored as content
+const maxLinkTarget = 4096
+
+// UnpackError reports an archive entry that couldn't be extracted
+type UnpackError struct {
+	Entry string
+	Err   error
+}
+
+func (e *UnpackError) Error() string {
+	return fmt.Sprintf("unpack %s: %v", e.Entry, e.Err)
+}
+
+func (e *UnpackError) Unwrap() error {
+	return e.Err
+}
+
+// extractor writes archive entries below dest, keeping symlinks and
+// hardlinks inside it and enforcing the limits
+type extractor struct {
+	dest        string
+	limits      UnpackLimits
+	archiveSize int64                    // size of the archive for the ratio limit, 0 if unknown
+	single      string                   // file name of single compressed files
+	rooted      bool                     // absolute link targets are below dest, as in system packages
+	bar         *progressbar.ProgressBar // progress of the extracted bytes, may be nil
+
+	entries int
+	size    int64
+}
+
+// newExtractor returns an extractor for dest with the default limits
+func newExtractor(dest string) *extractor {
+	return &extractor{dest: dest, limits: DefaultUnpackLimits()}
+}
+
+// target counts the entry name and returns its path below dest
+func (x *extractor) target(name string) (string, error) {
+	x.entries++
+	if x.limits.MaxEntries > 0 && x.entries > x.limits.MaxEntries {
+		return "", &UnpackError{Entry: name, Err: fmt.Errorf("%w: more than %d entries", ErrUnpackLimit, x.limits.MaxEntries)}
+	}
+	target, err := sanitizePath(x.dest, name)
+	if err != nil {
+		return "", &UnpackError{Entry: name, Err: err}
+	}
+	return target, nil
+}
+
+// mkdir creates the directory entry name
+func (x *extractor) mkdir(name string) error {
+	target, err := x.target(name)
+	if err != nil {
+		return err
+	}
+	return os.MkdirAll(target, 0755)
+}
+
+// writeFile writes the content of the file entry name with the permissions
+// of mode, without setuid, setgid or sticky bits
+func (x *extractor) writeFile(name string, r io.Reader, mode os.FileMode) error {
+	target, err := x.target(name)
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(target), 0755); err != nil {
+		return err
+	}
+
+	f, err := os.OpenFile(target, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, mode.Perm())
+	if err != nil {
+		return err
+	}
+	if _, err := io.Copy(f, &limitedReader{r: r, x: x, name: name}); err != nil {
+		return errors.Join(err, f.Close())
+	}
+	return f.Close()
+}
+
+// symlink creates the symlink entry name pointing to linkTarget, which must
+// resolve inside dest. Absolute targets are only allowed for rooted
+// extractors and made relative to dest. The target is cleaned, so that the link resolves the
+// same way once other links along its path are extracted.
+func (x *extractor) symlink(name, linkTarget string) error {
+	target, err := x.target(name)
+	if err != nil {
+		return err
+	}
+	unsafe := &UnpackError{Entry: name, Err: fmt.Errorf("%w: link to %q escapes destination directory", ErrUnsafePath, linkTarget)}
+	absolute := filepath.IsAbs(linkTarget) || strings.HasPrefix(linkTarget, "/")
+	if linkTarget == "" || absolute && !x.rooted {
+		return unsafe
+	}
+	if absolute {
+		// Resolve the target against dest as the root of the package
+		rel, err := filepath.Rel(filepath.Dir(target), filepath.Join(x.dest, filepath.Clean(filepath.FromSlash("/"+linkTarget))))
+		if err != nil {
+			return unsafe
+		}
+		linkTarget = rel
+	}
+	linkTarget = filepath.Clean(filepath.FromSlash(linkTarget))
+
+	if err := os.MkdirAll(filepath.Dir(target), 0755); err != nil {
+		return err
+	}
+	dest, err := filepath.EvalSymlinks(x.dest)
+	if err != nil {
+		return err
+	}
+	parent, err := filepath.EvalSymlinks(filepath.Dir(target))
+	if err != nil {
+		return err
+	}
+	if !isWithin(dest, filepath.Join(parent, linkTarget)) {
+		return unsafe
+	}
+
+	if info, err := os.Lstat(target); err == nil && !info.IsDir() {
+		if err := os.Remove(target); err != nil {
+			return err
+		}
+	}
+	return os.Symlink(linkTarget, target)
+}
+
+// hardlink creates the hardlink entry name to the regular file entry
+// linkTarget extracted before
+func (x *extractor) hardlink(name, linkTarget string) error {
+	target, err := x.target(name)
+	if err != nil {
+		return err
+	}
+	src, err := sanitizePath(x.dest, linkTarget)
+	if err != nil {
+		return &UnpackError{Entry: name, Err: err}
+	}
+	info, err := os.Lstat(src)
+	if err != nil || !info.Mode().IsRegular() {
+		return &UnpackError{Entry: name, Err: fmt.Errorf("%w: hardlink to %q, which is no extracted file", ErrInvalidAsset, linkTarget)}
+	}
+
+	if err := os.MkdirAll(filepath.Dir(target), 0755); err != nil {
+		return err
+	}
+	if err := os.Remove(target); err != nil && !os.IsNotExist(err) {
+		return err
+	}
+	return os.Link(src, target)
+}
+
+// readLinkTarget reads a symlink target stored as entry content, as in zip,
+// 7z and cpio archives
+func readLinkTarget(name string, r io.Reader) (string, error) {
+	target, err := io.ReadAll(io.LimitReader(r, maxLinkTarget+1))
+	if err != nil {
+		return "", err
+	}
+	if len(target) > maxLinkTarget {
+		return "", &UnpackError{Entry: name, Err: fmt.Errorf("%w: symlink target too long", ErrInvalidAsset)}
+	}
+	return string(target), nil
+}
+
+// expect sets the total of the progress bar to the extracted size, when
+// the format records it
+func (x *extractor) expect(total int64) {
+	if x.bar != nil && total > 0 {
+		x.bar.ChangeMax64(total)
+	}
+}
+
+// checkSize checks the extracted size against the size and ratio limits
+func (x *extractor) checkSize(name string) error {
+	if x.limits.MaxSize > 0 && x.size > x.limits.MaxSize {
+		return &UnpackError{Entry: name, Err: fmt.Errorf("%w: extracts more than %d bytes", ErrUnpackLimit, x.limits.MaxSize)}
+	}
+	if x.limits.MaxRatio > 0 && x.archiveSize > 0 && x.size > ratioGrace && x.size > int64(x.limits.MaxRatio)*x.archiveSize {
+		return &UnpackError{Entry: name, Err: fmt.Errorf("%w: compression ratio above %d", ErrUnpackLimit, x.limits.MaxRatio)}
+	}
+	return nil
+}
+
+// limitedReader counts the bytes read from r against the limits of x
+type limitedReader struct {
+	r    io.Reader
+	x    *extractor
+	name string
+}
+
+func (l *limitedReader) Read(p []byte) (int, error) {
+	n, err := l.r.Read(p)
+	l.x.size += int64(n)
+	if l.x.bar != nil {
+		_ = l.x.bar.Add(n)
+	}
+	if lerr := l.x.checkSize(l.name); lerr != nil {
+		return n, lerr
+	}
+	return n, err
+}
+
+// isWithin reports whether path is dir or below it
+func isWithin(dir, path string) bool {
+	rel, err := filepath.Rel(dir, path)
+	return err == nil && rel != ".." && !strings.HasPrefix(rel, ".."+string(filepath.Separator)) && !filepath.IsAbs(rel)
+}
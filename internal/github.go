@@ -2,20 +2,41 @@ package grip
 
 import (
 	"context"
+	"fmt"
+	"net/http"
+	"net/url"
 
 	"github.com/google/go-github/v56/github"
 )
 
+const defaultAPIBaseURL = "https://api.github.com/"
+
 // GitHubClientImpl implements GitHubClient using real GitHub API
 type GitHubClientImpl struct {
 	client *github.Client
 }
 
-// NewGitHubClient creates a new GitHub client
-func NewGitHubClient() *GitHubClientImpl {
-	return &GitHubClientImpl{
-		client: github.NewClient(nil),
+// NewGitHubClient creates a new GitHub client using httpClient for requests.
+// Requests are authenticated with the configured token, if any, and mirror
+// rules from cfg are applied to the API base URL.
+func NewGitHubClient(httpClient *http.Client, cfg *Config) (*GitHubClientImpl, error) {
+	client := github.NewClient(httpClient)
+	if cfg.Token != "" {
+		client = client.WithAuthToken(cfg.Token)
+	}
+
+	if baseURL := cfg.RewriteURL(defaultAPIBaseURL); baseURL != defaultAPIBaseURL {
+		u, err := url.Parse(baseURL)
+		if err != nil {
+			return nil, fmt.Errorf("parse API base URL: %w", err)
+		}
+		if u.Path == "" || u.Path[len(u.Path)-1] != '/' {
+			u.Path += "/"
+		}
+		client.BaseURL = u
 	}
+
+	return &GitHubClientImpl{client: client}, nil
 }
 
 // GetLatestRelease fetches the latest release
@@ -29,3 +50,24 @@ func (g *GitHubClientImpl) GetReleaseByTag(ctx context.Context, owner, repo, tag
 	release, _, err := g.client.Repositories.GetReleaseByTag(ctx, owner, repo, tag)
 	return release, err
 }
+
+// GetRepository fetches the repository metadata, e.g. description and license
+func (g *GitHubClientImpl) GetRepository(ctx context.Context, owner, repo string) (*github.Repository, error) {
+	repository, _, err := g.client.Repositories.Get(ctx, owner, repo)
+	return repository, err
+}
+
+// SearchRepositories searches repositories, returning at most limit of them
+// in the order of the best match
+func (g *GitHubClientImpl) SearchRepositories(ctx context.Context, query string, limit int) ([]*github.Repository, error) {
+	opts := &github.SearchOptions{ListOptions: github.ListOptions{PerPage: limit}}
+	result, _, err := g.client.Search.Repositories(ctx, query, opts)
+	if err != nil {
+		return nil, err
+	}
+	repos := result.Repositories
+	if len(repos) > limit {
+		repos = repos[:limit]
+	}
+	return repos, nil
+}
@@ -67,11 +67,12 @@ func SelfUpdate(ctx context.Context, version string, installer *Installer) error
 	asset.Tag = latestTag
 
 	// Download and unpack using installer
-	binPath, cleanup, err := installer.downloadAndUnpack(ctx, asset)
+	files, cleanup, err := installer.downloadAndUnpack(ctx, asset)
 	if err != nil {
 		return err
 	}
 	defer cleanup()
+	binPath := files.bins[0]
 
 	// Apply self-update
 	reader, err := os.Open(binPath)
@@ -0,0 +1,77 @@
+package grip
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+	"net"
+	"net/http"
+	"net/url"
+	"os"
+	"time"
+
+	"golang.org/x/net/http/httpproxy"
+)
+
+// NewHTTPClient creates an HTTP client optimized for downloading large binary
+// files, honoring the proxy and CA settings from cfg.
+//
+// There is no overall timeout: large downloads on slow links are bounded by
+// the idle timeout in Download instead.
+func NewHTTPClient(cfg *Config) (*http.Client, error) {
+	rootCAs, err := loadCertPool(cfg.CACerts)
+	if err != nil {
+		return nil, err
+	}
+
+	// Credentials for authenticating proxies are taken from the proxy URL
+	proxy := (&httpproxy.Config{
+		HTTPProxy:  cfg.HTTPProxy,
+		HTTPSProxy: cfg.HTTPSProxy,
+		NoProxy:    cfg.NoProxy,
+	}).ProxyFunc()
+
+	return &http.Client{
+		Transport: &http.Transport{
+			Proxy: func(req *http.Request) (*url.URL, error) {
+				return proxy(req.URL)
+			},
+			DialContext: (&net.Dialer{
+				Timeout:   30 * time.Second,
+				KeepAlive: 30 * time.Second,
+			}).DialContext,
+			TLSClientConfig:       &tls.Config{RootCAs: rootCAs},
+			TLSHandshakeTimeout:   10 * time.Second,
+			ResponseHeaderTimeout: 30 * time.Second,
+			MaxIdleConns:          10,
+			MaxIdleConnsPerHost:   5,
+			IdleConnTimeout:       90 * time.Second,
+			DisableCompression:    true, // Don't decompress, we handle archives
+		},
+	}, nil
+}
+
+// loadCertPool returns the system pool extended with the given PEM bundles.
+// A nil pool means the system default should be used.
+func loadCertPool(bundles []string) (*x509.CertPool, error) {
+	if len(bundles) == 0 {
+		return nil, nil
+	}
+
+	pool, err := x509.SystemCertPool()
+	if err != nil {
+		pool = x509.NewCertPool()
+	}
+
+	for _, path := range bundles {
+		pem, err := os.ReadFile(path)
+		if err != nil {
+			return nil, fmt.Errorf("read CA bundle: %w", err)
+		}
+		if !pool.AppendCertsFromPEM(pem) {
+			return nil, fmt.Errorf("no certificates found in CA bundle %s", path)
+		}
+	}
+
+	return pool, nil
+}
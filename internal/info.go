@@ -0,0 +1,184 @@
+package grip
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/alexjoedt/grip/internal/logger"
+	"github.com/alexjoedt/grip/internal/semver"
+	"github.com/google/go-github/v56/github"
+)
+
+// notesExcerptLines is the number of release notes lines shown by info
+const notesExcerptLines = 10
+
+// Info describes a package: its installation, if any, the repository and
+// its latest release
+type Info struct {
+	Repo        string        `json:"repo"`
+	Description string        `json:"description,omitempty"`
+	License     string        `json:"license,omitempty"`
+	Installed   *Installation `json:"installed,omitempty"`
+	Latest      *ReleaseInfo  `json:"latest,omitempty"`
+
+	// UpdateAvailable reports whether the latest release is newer than the
+	// installed one
+	UpdateAvailable bool `json:"updateAvailable,omitempty"`
+}
+
+// ReleaseInfo describes a release and how grip ranks its assets for the
+// platform
+type ReleaseInfo struct {
+	Tag         string      `json:"tag"`
+	Name        string      `json:"name,omitempty"`
+	PublishedAt time.Time   `json:"publishedAt"`
+	URL         string      `json:"url,omitempty"`
+	Notes       string      `json:"notes,omitempty"` // the first lines of the release notes
+	Platform    string      `json:"platform"`        // os/arch the assets are ranked for
+	Pattern     string      `json:"assetPattern,omitempty"`
+	Selected    string      `json:"selected,omitempty"`    // asset grip installs, if any
+	SelectError string      `json:"selectError,omitempty"` // why no asset is installed
+	Assets      []AssetInfo `json:"assets"`
+}
+
+// AssetInfo is a release asset with its ranking, best first
+type AssetInfo struct {
+	Name    string   `json:"name"`
+	Size    int      `json:"size"`
+	Score   int      `json:"score"`
+	Reasons []string `json:"reasons"`
+}
+
+// Info returns the installation of the installed name or repository, if
+// any, and the metadata and latest release of its repository. For installed
+// packages, failing GitHub requests are warnings, the installation is
+// returned nevertheless.
+func (i *Installer) Info(ctx context.Context, nameOrRepo string) (*Info, error) {
+	info := &Info{}
+	if inst, err := i.storage.Get(nameOrRepo); err == nil {
+		info.Installed = inst
+	} else if inst, err := i.storage.GetByRepo(nameOrRepo); err == nil {
+		info.Installed = inst
+	}
+
+	repo := nameOrRepo
+	if info.Installed != nil {
+		repo = info.Installed.Repo
+	}
+	owner, name, err := ParseRepoPath(repo)
+	if err != nil {
+		return nil, fmt.Errorf("%s is neither installed nor a repository: %w", nameOrRepo, err)
+	}
+	info.Repo = "github.com/" + owner + "/" + name
+
+	// Network failures don't hide what is known about an installation
+	fail := func(err error) (*Info, error) {
+		if info.Installed == nil {
+			return nil, err
+		}
+		logger.Warn("%v", err)
+		return info, nil
+	}
+
+	repository, err := i.ghClient.GetRepository(ctx, owner, name)
+	if err != nil {
+		return fail(fmt.Errorf("fetch repository: %w", err))
+	}
+	info.Description = repository.GetDescription()
+	if license := repository.GetLicense(); license != nil {
+		info.License = license.GetSPDXID()
+		if info.License == "" || info.License == "NOASSERTION" {
+			info.License = license.GetName()
+		}
+	}
+
+	release, err := i.fetchRelease(ctx, owner, name, "")
+	if err != nil {
+		return fail(err)
+	}
+	info.Latest = i.releaseInfo(release, owner, name, info.Installed)
+
+	if info.Installed != nil {
+		info.UpdateAvailable = isNewer(info.Latest.Tag, info.Installed.Tag)
+	}
+	return info, nil
+}
+
+// releaseInfo describes release and ranks its assets the way install does,
+// with the asset pattern of the installation or package spec
+func (i *Installer) releaseInfo(release *github.RepositoryRelease, owner, name string, inst *Installation) *ReleaseInfo {
+	ri := &ReleaseInfo{
+		Tag:         release.GetTagName(),
+		Name:        release.GetName(),
+		PublishedAt: release.GetPublishedAt().Time,
+		URL:         release.GetHTMLURL(),
+		Notes:       excerpt(release.GetBody(), notesExcerptLines),
+		Platform:    i.config.Platform().String(),
+		Assets:      []AssetInfo{},
+	}
+
+	ri.Pattern = i.assetPattern(owner, name, inst)
+
+	for _, c := range rankAssets(release.Assets, i.config, name) {
+		ri.Assets = append(ri.Assets, AssetInfo{Name: c.asset.GetName(), Size: c.asset.GetSize(), Score: c.score, Reasons: c.reasons})
+	}
+
+	selected, err := i.selectReleaseAsset(release, name, ri.Pattern)
+	if err != nil {
+		ri.SelectError = err.Error()
+		return ri
+	}
+	ri.Selected = selected
+	return ri
+}
+
+// assetPattern returns the asset pattern install uses for the repository,
+// the one of the installation, if any, or of the package spec
+func (i *Installer) assetPattern(owner, name string, inst *Installation) string {
+	if inst != nil {
+		return inst.AssetPattern
+	}
+	if spec, ok := i.config.PackageSpec("github.com/" + owner + "/" + name); ok {
+		return spec.AssetPattern
+	}
+	return ""
+}
+
+// selectReleaseAsset returns the name of the release asset install selects
+// for the platform with the asset pattern, if any, without asking or logging
+func (i *Installer) selectReleaseAsset(release *github.RepositoryRelease, name, pattern string) (string, error) {
+	var match func(string) bool
+	if pattern != "" {
+		var err error
+		if match, err = compileAssetPattern(pattern, i.config, release.GetTagName()); err != nil {
+			return "", err
+		}
+	}
+	asset, err := selectAssetQuietly(release.Assets, i.config, name, match)
+	if err != nil {
+		return "", err
+	}
+	return asset.GetName(), nil
+}
+
+// excerpt returns the first lines of text, marking the cut with "..."
+func excerpt(text string, lines int) string {
+	all := strings.Split(strings.TrimSpace(strings.ReplaceAll(text, "\r\n", "\n")), "\n")
+	if len(all) <= lines {
+		return strings.Join(all, "\n")
+	}
+	return strings.Join(all[:lines], "\n") + "\n..."
+}
+
+// isNewer reports whether tag latest is newer than installed, comparing them
+// as semantic versions if possible
+func isNewer(latest, installed string) bool {
+	lv, err1 := semver.Parse(latest)
+	iv, err2 := semver.Parse(installed)
+	if err1 != nil || err2 != nil {
+		return latest != installed
+	}
+	return semver.Compare(lv, iv) > 0
+}
@@ -2,11 +2,14 @@ package grip
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"io"
 	"net/http"
 	"os"
 	"os/exec"
 	"path/filepath"
+	"slices"
 	"time"
 
 	"github.com/alexjoedt/grip/internal/logger"
@@ -17,6 +20,8 @@ import (
 type GitHubClient interface {
 	GetLatestRelease(ctx context.Context, owner, repo string) (*github.RepositoryRelease, error)
 	GetReleaseByTag(ctx context.Context, owner, repo, tag string) (*github.RepositoryRelease, error)
+	GetRepository(ctx context.Context, owner, repo string) (*github.Repository, error)
+	SearchRepositories(ctx context.Context, query string, limit int) ([]*github.Repository, error)
 }
 
 // Installer coordinates installation operations
@@ -25,6 +30,7 @@ type Installer struct {
 	storage    *Storage
 	ghClient   GitHubClient
 	httpClient *http.Client
+	stdin      io.Reader // answers to prompts
 }
 
 // NewInstaller creates a new installer
@@ -34,6 +40,7 @@ func NewInstaller(cfg *Config, storage *Storage, ghClient GitHubClient, httpClie
 		storage:    storage,
 		ghClient:   ghClient,
 		httpClient: httpClient,
+		stdin:      os.Stdin,
 	}
 }
 
@@ -42,97 +49,218 @@ func (i *Installer) Config() *Config {
 	return i.config
 }
 
+// Storage returns the installer's registry
+func (i *Installer) Storage() *Storage {
+	return i.storage
+}
+
+// checkWritable fails for system wide changes without root privileges
+func (i *Installer) checkWritable() error {
+	if i.config.System && !isRoot() {
+		return ErrNotRoot
+	}
+	return nil
+}
+
 // InstallOptions holds installation parameters
 type InstallOptions struct {
-	Repo  string
-	Tag   string
-	Force bool
-	Alias string
+	Repo        string
+	Tag         string
+	Force       bool
+	Alias       string
+	Destination string // absolute install directory, defaults to the bin dir
+
+	AssetPattern string // selects the asset instead of ranking, see compileAssetPattern
+	Binary       string // file to install from the archive instead of detecting it
+	Interactive  bool   // let the user choose between ambiguous assets
+
+	SkipArchCheck bool // install binaries built for another platform, reused by update
+
+	Bins    []string // further executables to install from the archive, reused by update
+	AllBins bool     // install every executable of the archive, reused by update
+	Scripts bool     // consider #! scripts as executables, reused by update
+
+	// Target platform instead of the host's. Installations for another
+	// platform need a destination other than the bin dir and aren't recorded.
+	OS   string
+	Arch string
 }
 
-// Install installs a package from GitHub
+// Install installs a package from GitHub. Without an asset pattern or binary,
+// the package spec configured or built in for the repository is used.
 func (i *Installer) Install(ctx context.Context, opts InstallOptions) error {
+	if opts.AssetPattern == "" && opts.Binary == "" {
+		if spec, ok := i.config.PackageSpec(opts.Repo); ok {
+			logger.Info("Using package spec for %s: asset pattern %q, binary %q", opts.Repo, spec.AssetPattern, spec.Binary)
+			opts.AssetPattern = spec.AssetPattern
+			opts.Binary = spec.Binary
+		}
+	}
+	return i.install(ctx, opts)
+}
+
+func (i *Installer) install(ctx context.Context, opts InstallOptions) error {
+	if err := i.checkWritable(); err != nil {
+		return err
+	}
+
 	owner, name, err := ParseRepoPath(opts.Repo)
 	if err != nil {
 		return err
 	}
 
-	// Use alias as name if provided
+	if err := ValidateAssetPattern(opts.AssetPattern); err != nil {
+		return err
+	}
+
+	if opts.AllBins && len(opts.Bins) > 0 {
+		return errors.New("use either --bin or --all-bins")
+	}
+	// The first named executable is the main one, installed under the alias
+	if opts.Binary == "" && len(opts.Bins) > 0 {
+		opts.Binary, opts.Bins = opts.Bins[0], opts.Bins[1:]
+	}
+
+	// Assets and binaries are selected for the target platform
+	platform := i.config.Platform().Override(opts.OS, opts.Arch)
+	cfg := i.config.ForPlatform(platform)
+	cross := platform != i.config.Platform()
+
+	destDir := i.config.BinDir
+	if opts.Destination != "" {
+		if !filepath.IsAbs(opts.Destination) {
+			return fmt.Errorf("%w: %s", ErrNoAbsolutePath, opts.Destination)
+		}
+		destDir = filepath.Clean(opts.Destination)
+	}
+	if cross && destDir == i.config.BinDir {
+		return fmt.Errorf("%w: use --destination to install for %s outside %s", ErrCrossTarget, platform, i.config.BinDir)
+	}
+
+	// Use alias or binary as name if provided
 	installName := name
 	if opts.Alias != "" {
 		installName = opts.Alias
+	} else if opts.Binary != "" {
+		installName = binaryBase(opts.Binary)
 	}
 
-	// Check if already installed
-	existing, err := i.storage.GetByRepo(opts.Repo)
-	if err == nil && !opts.Force {
-		return fmt.Errorf("%s version %s is already installed", existing.Name, existing.Tag)
-	}
+	// Installations for another platform don't run on the host, they
+	// neither conflict with its installations nor are recorded
+	var existing *Installation
+	if !cross {
+		// Check if already installed
+		existing, err = i.storage.GetByRepo(opts.Repo)
+		if err == nil && !opts.Force {
+			return fmt.Errorf("%s version %s is already installed", existing.Name, existing.Tag)
+		}
 
-	// Check if name conflicts with another source
-	if _, err := exec.LookPath(installName); err == nil && existing == nil {
-		return fmt.Errorf("%s is already installed from another source", installName)
+		// Check if name conflicts with another source
+		if _, err := exec.LookPath(installName); err == nil && existing == nil {
+			return fmt.Errorf("%s is already installed from another source", installName)
+		}
 	}
 
-	// Fetch release
-	var release *github.RepositoryRelease
-	if opts.Tag == "" {
-		logger.Info("Fetching latest release for %s/%s", owner, name)
-		release, err = i.ghClient.GetLatestRelease(ctx, owner, name)
-	} else {
-		logger.Info("Fetching release %s for %s/%s", opts.Tag, owner, name)
-		release, err = i.ghClient.GetReleaseByTag(ctx, owner, name, opts.Tag)
-	}
+	release, err := i.fetchRelease(ctx, owner, name, opts.Tag)
 	if err != nil {
-		return fmt.Errorf("fetch release: %w", err)
+		return err
 	}
 
-	// Parse asset for current platform
-	asset, err := parseAsset(release.Assets, i.config, owner, name)
+	// Select asset for current platform
+	var match func(string) bool
+	if opts.AssetPattern != "" {
+		if match, err = compileAssetPattern(opts.AssetPattern, cfg, release.GetTagName()); err != nil {
+			return err
+		}
+	}
+	var pick pickFn
+	if opts.Interactive {
+		pick = promptAsset(i.stdin)
+	}
+	asset, err := selectAsset(release.Assets, cfg, owner, name, release.GetTagName(), match, pick)
 	if err != nil {
 		return err
 	}
 
 	asset.Tag = *release.TagName
 	asset.Alias = opts.Alias
+	if opts.AssetPattern != "" {
+		asset.Pattern = opts.AssetPattern
+	}
+	asset.Binary = opts.Binary
+	asset.SkipArchCheck = opts.SkipArchCheck
+	asset.Bins = opts.Bins
+	asset.AllBins = opts.AllBins
+	asset.Scripts = opts.Scripts
 
 	// Install asset
-	if err := i.installAsset(ctx, asset); err != nil {
+	files, err := i.installAsset(ctx, asset, destDir)
+	if err != nil {
 		return fmt.Errorf("install: %w", err)
 	}
-
-	// Calculate SHA256 of installed binary
-	binPath := filepath.Join(i.config.BinDir, installName)
-	sha256Hash, err := calculateFileSHA256(binPath)
-	if err != nil {
-		logger.Warn("Could not calculate SHA256: %v", err)
+	if cross {
+		logger.Success("%s@%s for %s installed to %s", files.bins[0], asset.Tag, platform, destDir)
+		return nil
 	}
 
-	// Save to storage
-	now := time.Now()
-	inst := &Installation{
-		Name:        installName,
-		Alias:       opts.Alias,
-		Repo:        opts.Repo,
-		Tag:         asset.Tag,
-		SHA256:      sha256Hash,
-		InstalledAt: now,
-		UpdatedAt:   now,
-		InstallPath: i.config.BinDir,
+	if err := i.saveInstallation(opts.Repo, asset, destDir, files); err != nil {
+		return err
 	}
-
-	if err := i.storage.Save(inst); err != nil {
-		return fmt.Errorf("save installation: %w", err)
+	if existing != nil {
+		i.removeReplaced(existing, destDir, files)
 	}
 
-	if !i.config.CheckPathEnv() {
-		logger.Warn("The grip path '%s' isn't in PATH", i.config.BinDir)
+	if !inPath(destDir) {
+		logger.Warn("The grip path '%s' isn't in PATH", destDir)
 	}
 
 	logger.Success("%s@%s installed successfully", installName, asset.Tag)
 	return nil
 }
 
+// removeReplaced deletes the files of the existing installation that aren't
+// part of its replacement installed to destDir anymore: the man pages and
+// completions, and the binaries when they were moved to another directory
+// or aren't selected anymore
+func (i *Installer) removeReplaced(existing *Installation, destDir string, files *installed) {
+	i.removeOwnShareFiles(existing.Name, slices.DeleteFunc(slices.Clone(existing.ShareFiles), func(p string) bool {
+		return slices.Contains(files.share, p)
+	}))
+
+	if existing.InstallPath == "" {
+		return
+	}
+	for _, f := range existing.InstalledFiles() {
+		moved := existing.InstallPath != destDir
+		dropped := f != existing.Name && !slices.Contains(files.bins, f)
+		if !moved && !dropped {
+			continue
+		}
+		oldPath := filepath.Join(existing.InstallPath, f)
+		if err := os.Remove(oldPath); err != nil && !os.IsNotExist(err) {
+			logger.Warn("Could not remove previous binary %s: %v", oldPath, err)
+		}
+	}
+}
+
+// fetchRelease fetches the release with the given tag, or the latest
+// release when tag is empty.
+func (i *Installer) fetchRelease(ctx context.Context, owner, name, tag string) (*github.RepositoryRelease, error) {
+	var release *github.RepositoryRelease
+	var err error
+	if tag == "" {
+		logger.Info("Fetching latest release for %s/%s", owner, name)
+		release, err = i.ghClient.GetLatestRelease(ctx, owner, name)
+	} else {
+		logger.Info("Fetching release %s for %s/%s", tag, owner, name)
+		release, err = i.ghClient.GetReleaseByTag(ctx, owner, name, tag)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("fetch release: %w", err)
+	}
+	return release, nil
+}
+
 // Update updates an installed package
 func (i *Installer) Update(ctx context.Context, name string) error {
 	// Get current installation
@@ -141,24 +269,31 @@ func (i *Installer) Update(ctx context.Context, name string) error {
 		return fmt.Errorf("package not found: %s", name)
 	}
 
-	// Install with force flag
+	// Install with force flag to the same place, selecting the asset the same way
 	opts := InstallOptions{
-		Repo:  inst.Repo,
-		Tag:   "", // Get latest
-		Force: true,
-		Alias: inst.Alias,
+		Repo:          inst.Repo,
+		Tag:           "", // Get latest
+		Force:         true,
+		Alias:         inst.Alias,
+		Destination:   inst.InstallPath,
+		AssetPattern:  inst.AssetPattern,
+		Binary:        inst.Binary,
+		SkipArchCheck: inst.SkipArchCheck,
+		Bins:          inst.Bins,
+		AllBins:       inst.AllBins,
+		Scripts:       inst.Scripts,
 	}
 
-	return i.Install(ctx, opts)
+	return i.install(ctx, opts)
 }
 
 // downloadAndUnpack downloads an asset archive and unpacks it.
-// Returns the path to the extracted executable and a cleanup function.
+// Returns the extracted files and a cleanup function.
 // The caller is responsible for calling cleanup when done.
-func (i *Installer) downloadAndUnpack(ctx context.Context, asset *Asset) (string, func(), error) {
+func (i *Installer) downloadAndUnpack(ctx context.Context, asset *Asset) (*unpacked, func(), error) {
 	ws, err := NewWorkspace(i.config.TempDir, asset.Name)
 	if err != nil {
-		return "", nil, fmt.Errorf("create workspace: %w", err)
+		return nil, nil, fmt.Errorf("create workspace: %w", err)
 	}
 	cleanup := func() {
 		if cleanupErr := ws.Cleanup(); cleanupErr != nil {
@@ -166,47 +301,255 @@ func (i *Installer) downloadAndUnpack(ctx context.Context, asset *Asset) (string
 		}
 	}
 
-	if err := Download(ctx, i.httpClient, asset.DownloadURL, ws.DownloadDir(), asset.Name); err != nil {
+	downloadURL := i.config.RewriteURL(asset.DownloadURL)
+	if downloadURL != asset.DownloadURL {
+		logger.Info("Using mirror %s", downloadURL)
+	}
+
+	policy := defaultDownloadPolicy
+	policy.Steps = assetSteps(asset.Name)
+	if err := download(ctx, i.httpClient, downloadURL, ws.DownloadDir(), asset.Name, policy); err != nil {
 		cleanup()
-		return "", nil, fmt.Errorf("download: %w", err)
+		return nil, nil, fmt.Errorf("download: %w", err)
 	}
 
 	archivePath := filepath.Join(ws.DownloadDir(), asset.Name)
-	binPath, err := Unpack(archivePath, ws.UnpackDir())
+
+	files, err := unpackAsset(archivePath, ws.UnpackDir(), asset, i.config.UnpackLimits)
 	if err != nil {
 		cleanup()
-		return "", nil, fmt.Errorf("unpack: %w", err)
+		return nil, nil, fmt.Errorf("unpack: %w", err)
 	}
 
-	return binPath, cleanup, nil
+	return files, cleanup, nil
+}
+
+// unpacked holds the files of an unpacked asset to install
+type unpacked struct {
+	bins  []string    // executables, the main one first
+	share []shareFile // man pages and shell completions
+	steps []string    // installation steps for the progress bars
+}
+
+// installed holds the files of an installed asset
+type installed struct {
+	bins  []string // names of the executables in the install directory
+	share []string // paths of the man pages and shell completions
 }
 
 // installAsset orchestrates the complete installation workflow for an asset.
-func (i *Installer) installAsset(ctx context.Context, asset *Asset) error {
-	binPath, cleanup, err := i.downloadAndUnpack(ctx, asset)
+func (i *Installer) installAsset(ctx context.Context, asset *Asset, destDir string) (*installed, error) {
+	files, cleanup, err := i.downloadAndUnpack(ctx, asset)
 	if err != nil {
-		return err
+		return nil, err
 	}
 	defer cleanup()
 
-	if err := InstallBinary(binPath, i.config.BinDir, asset.BinaryName()); err != nil {
-		return fmt.Errorf("install: %w", err)
+	return i.installUnpacked(files, destDir, asset)
+}
+
+// installArchive unpacks a local asset archive into unpackDir and installs
+// the files found in it to destDir.
+func (i *Installer) installArchive(archivePath, unpackDir, destDir string, asset *Asset) (*installed, error) {
+	files, err := unpackAsset(archivePath, unpackDir, asset, i.config.UnpackLimits)
+	if err != nil {
+		return nil, fmt.Errorf("unpack: %w", err)
+	}
+
+	return i.installUnpacked(files, destDir, asset)
+}
+
+// installUnpacked installs the executables of an unpacked asset to destDir
+// and its man pages and shell completions to the share directory, unless the
+// asset is for another platform than the host
+func (i *Installer) installUnpacked(files *unpacked, destDir string, asset *Asset) (*installed, error) {
+	bins, err := i.installBinaries(files.bins, destDir, asset, files.steps)
+	if err != nil {
+		return nil, err
+	}
+	if (Platform{OS: asset.OS, Arch: asset.Arch}) != i.config.Platform() {
+		return &installed{bins: bins}, nil
+	}
+	share, err := i.installShareFiles(bins[0], files.share)
+	if err != nil {
+		removeShareFiles(share)
+		return nil, err
+	}
+	return &installed{bins: bins, share: share}, nil
+}
+
+// installBinaries checks and installs the unpacked executables to destDir,
+// the main one first under the name of the asset, the others under their own
+// names, labelling the progress bars by steps. It returns the names of the
+// installed files.
+func (i *Installer) installBinaries(binPaths []string, destDir string, asset *Asset, steps []string) ([]string, error) {
+	names := []string{installedName(binPaths[0], asset.BinaryName())}
+	for _, p := range binPaths[1:] {
+		names = append(names, filepath.Base(p))
+	}
+	if err := i.checkOwners(destDir, names[0], names[1:]); err != nil {
+		return nil, err
+	}
+
+	for _, binPath := range binPaths {
+		if err := checkPlatform(binPath, asset); err != nil {
+			return nil, err
+		}
+	}
+	for n, binPath := range binPaths {
+		if err := installBinary(binPath, destDir, names[n], steps); err != nil {
+			return nil, fmt.Errorf("install: %w", err)
+		}
+	}
+	return names, nil
+}
+
+// checkOwners checks that none of the names in destDir belongs to another
+// installation than the one named main
+func (i *Installer) checkOwners(destDir, main string, names []string) error {
+	installations, err := i.storage.List()
+	if err != nil {
+		return err
+	}
+	for _, inst := range installations {
+		if inst.Name == main || inst.InstallPath != destDir {
+			continue
+		}
+		for _, name := range names {
+			if slices.Contains(inst.InstalledFiles(), name) {
+				return fmt.Errorf("%w: %s is installed by %s", ErrAlreadyExists, name, inst.Name)
+			}
+		}
+	}
+	return nil
+}
+
+// unpackAsset unpacks an asset archive and returns the files to install.
+// The executables are first the binary named by the asset, or the detected
+// one, then those named by asset.Bins, or all with asset.AllBins.
+// Raw binaries are returned as they are, unless their content is an archive.
+// Archives are unpacked within limits.
+func unpackAsset(archivePath, unpackDir string, asset *Asset, limits UnpackLimits) (*unpacked, error) {
+	if isRawBinary(asset.Name) {
+		if ext, err := detectArchiveExt(archivePath); err != nil || ext == "" {
+			return &unpacked{bins: []string{archivePath}, steps: rawSteps}, checkExecutable(archivePath)
+		}
+	}
+	// Also raw binaries whose content is an archive are unpacked
+	steps := archiveSteps
+
+	var binPath string
+	var err error
+	if asset.Binary != "" {
+		binPath, err = unpackBinary(archivePath, unpackDir, asset.Binary, asset.OS == "windows", limits, steps)
+	} else {
+		binPath, err = unpackExecutable(archivePath, unpackDir, limits, steps, []string{asset.Alias, asset.RepoName}, asset.Scripts)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	binPaths := []string{binPath}
+	if asset.AllBins {
+		all, err := findExecutables(unpackDir, asset.Scripts)
+		if err != nil {
+			return nil, fmt.Errorf("find executables: %w", err)
+		}
+		for _, p := range all {
+			if p != binPath {
+				binPaths = append(binPaths, p)
+			}
+		}
+	}
+	for _, bin := range asset.Bins {
+		p, err := findBinary(unpackDir, bin, asset.OS == "windows")
+		if err != nil {
+			return nil, fmt.Errorf("find executable: %w", err)
+		}
+		if !slices.Contains(binPaths, p) {
+			binPaths = append(binPaths, p)
+		}
+	}
+
+	share, err := findShareFiles(unpackDir, binPaths)
+	if err != nil {
+		return nil, fmt.Errorf("find man pages and completions: %w", err)
+	}
+	return &unpacked{bins: binPaths, share: share, steps: steps}, nil
+}
+
+// checkPlatform checks the binary is built for the platform of the asset,
+// unless the check is skipped
+func checkPlatform(binPath string, asset *Asset) error {
+	if asset.SkipArchCheck {
+		logger.Info("Skipping the platform check of %s", filepath.Base(binPath))
+		return nil
+	}
+	return CheckBinaryPlatform(binPath, asset.OS, asset.Arch)
+}
+
+// saveInstallation records the asset installed to destDir as files in
+// storage. The original installation time is kept when an existing
+// installation is replaced.
+func (i *Installer) saveInstallation(repo string, asset *Asset, destDir string, files *installed) error {
+	installName := files.bins[0]
+
+	// Calculate SHA256 of installed binary
+	binPath := filepath.Join(destDir, installName)
+	sha256Hash, err := calculateFileSHA256(binPath)
+	if err != nil {
+		logger.Warn("Could not calculate SHA256: %v", err)
+	}
+
+	now := time.Now()
+	inst := &Installation{
+		Name:        installName,
+		Alias:       asset.Alias,
+		Repo:        repo,
+		Tag:         asset.Tag,
+		SHA256:      sha256Hash,
+		InstalledAt: now,
+		UpdatedAt:   now,
+		InstallPath: destDir,
+
+		AssetPattern:  asset.Pattern,
+		Binary:        asset.Binary,
+		SkipArchCheck: asset.SkipArchCheck,
+		Bins:          asset.Bins,
+		AllBins:       asset.AllBins,
+		Scripts:       asset.Scripts,
+		Files:         files.bins,
+		ShareFiles:    files.share,
+	}
+	if existing, err := i.storage.Get(installName); err == nil && !existing.InstalledAt.IsZero() {
+		inst.InstalledAt = existing.InstalledAt
+	}
+
+	if err := i.storage.Save(inst); err != nil {
+		return fmt.Errorf("save installation: %w", err)
 	}
 	return nil
 }
 
 // Remove removes an installed package
 func (i *Installer) Remove(name string) error {
+	if err := i.checkWritable(); err != nil {
+		return err
+	}
+
 	inst, err := i.storage.Get(name)
 	if err != nil {
 		return fmt.Errorf("package not found: %s", name)
 	}
 
-	// Delete binary
-	binPath := filepath.Join(inst.InstallPath, name)
-	if err := os.Remove(binPath); err != nil && !os.IsNotExist(err) {
-		return fmt.Errorf("remove binary: %w", err)
+	// Delete binaries
+	for _, f := range inst.InstalledFiles() {
+		binPath := filepath.Join(inst.InstallPath, f)
+		if err := os.Remove(binPath); err != nil && !os.IsNotExist(err) {
+			return fmt.Errorf("remove binary: %w", err)
+		}
 	}
+	i.removeOwnShareFiles(inst.Name, inst.ShareFiles)
 
 	// Remove from storage
 	if err := i.storage.Delete(name); err != nil {
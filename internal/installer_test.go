@@ -0,0 +1,700 @@
+package grip
+
+import (
+	"archive/zip"
+	"bytes"
+	"compress/gzip"
+	"context"
+	"debug/elf"
+	"debug/pe"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/google/go-github/v56/github"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// newTestRelease serves archive for every asset and returns a client releasing the given asset names
+func newTestRelease(t *testing.T, archive []byte, tag string, names ...string) (*fakeGitHubClient, *http.Client) {
+	t.Helper()
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write(archive)
+	}))
+	t.Cleanup(srv.Close)
+
+	release := &github.RepositoryRelease{TagName: github.String(tag)}
+	for _, name := range names {
+		release.Assets = append(release.Assets, &github.ReleaseAsset{
+			Name:               github.String(name),
+			BrowserDownloadURL: github.String(srv.URL + "/" + name),
+		})
+	}
+	return &fakeGitHubClient{release: release}, srv.Client()
+}
+
+// linuxArchive returns a tar.gz archive with a linux/amd64 executable
+func linuxArchive(t *testing.T) []byte {
+	t.Helper()
+	return gzipBytes(t, newTarStream(t, []tarEntry{
+		{name: "test-executable", content: elfBinary(elf.ELFCLASS64, elf.ELFDATA2LSB, elf.EM_X86_64, elf.ELFOSABI_NONE), mode: 0o755},
+	}))
+}
+
+// TestInstallDestination tests installing to a custom directory and updating in place
+func TestInstallDestination(t *testing.T) {
+	t.Parallel()
+
+	gh, client := newTestRelease(t, linuxArchive(t), "v1.0.0", "griptest_linux_amd64.tar.gz")
+	installer := newTestInstaller(t, gh, client, Platform{OS: "linux", Arch: "amd64"})
+	ctx := context.Background()
+
+	err := installer.Install(ctx, InstallOptions{Repo: "github.com/owner/griptest", Destination: "relative/bin"})
+	require.ErrorIs(t, err, ErrNoAbsolutePath)
+
+	dest := filepath.Join(t.TempDir(), "custom")
+	require.NoError(t, installer.Install(ctx, InstallOptions{Repo: "github.com/owner/griptest", Destination: dest}))
+	assert.FileExists(t, filepath.Join(dest, "griptest"))
+	assert.NoFileExists(t, filepath.Join(installer.config.BinDir, "griptest"))
+
+	inst, err := installer.storage.Get("griptest")
+	require.NoError(t, err)
+	assert.Equal(t, dest, inst.InstallPath)
+
+	// Update reinstalls to the recorded destination
+	gh.release.TagName = github.String("v1.1.0")
+	require.NoError(t, installer.Update(ctx, "griptest"))
+
+	inst, err = installer.storage.Get("griptest")
+	require.NoError(t, err)
+	assert.Equal(t, "v1.1.0", inst.Tag)
+	assert.Equal(t, dest, inst.InstallPath)
+	assert.NoFileExists(t, filepath.Join(installer.config.BinDir, "griptest"))
+
+	// Moving it back to the default bin dir removes the old binary
+	require.NoError(t, installer.Install(ctx, InstallOptions{Repo: "github.com/owner/griptest", Force: true}))
+	assert.FileExists(t, filepath.Join(installer.config.BinDir, "griptest"))
+	assert.NoFileExists(t, filepath.Join(dest, "griptest"))
+}
+
+// TestInstallArchCheck tests rejecting binaries of another platform and skipping the check
+func TestInstallArchCheck(t *testing.T) {
+	t.Parallel()
+
+	// createTestTarGz holds a darwin/amd64 executable
+	gh, client := newTestRelease(t, createTestTarGz(t), "v1.0.0", "griptest_linux_amd64.tar.gz")
+	installer := newTestInstaller(t, gh, client, Platform{OS: "linux", Arch: "amd64"})
+	ctx := context.Background()
+
+	err := installer.Install(ctx, InstallOptions{Repo: "github.com/owner/griptest"})
+	require.ErrorIs(t, err, ErrWrongPlatform)
+	assert.ErrorContains(t, err, "--skip-arch-check")
+	assert.NoFileExists(t, filepath.Join(installer.config.BinDir, "griptest"))
+
+	require.NoError(t, installer.Install(ctx, InstallOptions{Repo: "github.com/owner/griptest", SkipArchCheck: true}))
+	assert.FileExists(t, filepath.Join(installer.config.BinDir, "griptest"))
+
+	// Update keeps skipping the check
+	gh.release.TagName = github.String("v1.1.0")
+	require.NoError(t, installer.Update(ctx, "griptest"))
+	inst, err := installer.storage.Get("griptest")
+	require.NoError(t, err)
+	assert.True(t, inst.SkipArchCheck)
+	assert.Equal(t, "v1.1.0", inst.Tag)
+}
+
+// TestInstallMultipleBinaries tests installing several executables of one archive
+func TestInstallMultipleBinaries(t *testing.T) {
+	t.Parallel()
+
+	exe := elfBinary(elf.ELFCLASS64, elf.ELFDATA2LSB, elf.EM_X86_64, elf.ELFOSABI_NONE)
+	archive := gzipBytes(t, newTarStream(t, []tarEntry{
+		{name: "griptest/README.md", content: []byte("# griptest")},
+		{name: "griptest/griptest", content: exe, mode: 0o755},
+		{name: "griptest/griptest-convert", content: exe, mode: 0o755},
+		{name: "griptest/griptest-plugin", content: exe, mode: 0o755},
+	}))
+	gh, client := newTestRelease(t, archive, "v1.0.0", "griptest_linux_amd64.tar.gz")
+	installer := newTestInstaller(t, gh, client, Platform{OS: "linux", Arch: "amd64"})
+	binDir := installer.config.BinDir
+	ctx := context.Background()
+
+	err := installer.Install(ctx, InstallOptions{Repo: "github.com/owner/griptest", Bins: []string{"griptest"}, AllBins: true})
+	require.ErrorContains(t, err, "either --bin or --all-bins")
+
+	err = installer.Install(ctx, InstallOptions{Repo: "github.com/owner/griptest", Bins: []string{"griptest", "missing"}})
+	require.Error(t, err)
+	assert.NoFileExists(t, filepath.Join(binDir, "griptest"))
+
+	// The first named executable is the main one
+	require.NoError(t, installer.Install(ctx, InstallOptions{Repo: "github.com/owner/griptest", Bins: []string{"griptest", "griptest-convert"}}))
+	assert.FileExists(t, filepath.Join(binDir, "griptest"))
+	assert.FileExists(t, filepath.Join(binDir, "griptest-convert"))
+	assert.NoFileExists(t, filepath.Join(binDir, "griptest-plugin"))
+
+	inst, err := installer.storage.Get("griptest")
+	require.NoError(t, err)
+	assert.Equal(t, []string{"griptest", "griptest-convert"}, inst.Files)
+
+	// Update keeps the selection
+	gh.release.TagName = github.String("v1.1.0")
+	require.NoError(t, installer.Update(ctx, "griptest"))
+	inst, err = installer.storage.Get("griptest")
+	require.NoError(t, err)
+	assert.Equal(t, []string{"griptest", "griptest-convert"}, inst.Files)
+
+	// Reinstalling with all executables, then with the main one only, drops the others
+	require.NoError(t, installer.Install(ctx, InstallOptions{Repo: "github.com/owner/griptest", AllBins: true, Force: true}))
+	assert.FileExists(t, filepath.Join(binDir, "griptest-plugin"))
+	inst, err = installer.storage.Get("griptest")
+	require.NoError(t, err)
+	assert.ElementsMatch(t, []string{"griptest", "griptest-convert", "griptest-plugin"}, inst.Files)
+
+	require.NoError(t, installer.Install(ctx, InstallOptions{Repo: "github.com/owner/griptest", Force: true}))
+	assert.FileExists(t, filepath.Join(binDir, "griptest"))
+	assert.NoFileExists(t, filepath.Join(binDir, "griptest-convert"))
+	assert.NoFileExists(t, filepath.Join(binDir, "griptest-plugin"))
+
+	// Remove deletes every installed file
+	require.NoError(t, installer.Install(ctx, InstallOptions{Repo: "github.com/owner/griptest", AllBins: true, Force: true}))
+	require.NoError(t, installer.Remove("griptest"))
+	entries, err := os.ReadDir(binDir)
+	require.NoError(t, err)
+	assert.Empty(t, entries)
+}
+
+// TestInstallShareFiles tests installing and removing man pages and completions
+func TestInstallShareFiles(t *testing.T) {
+	t.Parallel()
+
+	archive := gzipBytes(t, newTarStream(t, []tarEntry{
+		{name: "griptest/griptest", content: elfBinary(elf.ELFCLASS64, elf.ELFDATA2LSB, elf.EM_X86_64, elf.ELFOSABI_NONE), mode: 0o755},
+		{name: "griptest/doc/griptest.1", content: []byte(".TH GRIPTEST 1")},
+		{name: "griptest/complete/griptest.bash", content: []byte("complete -F _griptest griptest")},
+		{name: "griptest/complete/_griptest", content: []byte("#compdef griptest")},
+		{name: "griptest/complete/griptest.fish", content: []byte("complete -c griptest")},
+	}))
+	gh, client := newTestRelease(t, archive, "v1.0.0", "griptest_linux_amd64.tar.gz")
+	installer := newTestInstaller(t, gh, client, Platform{OS: "linux", Arch: "amd64"})
+	shareDir := installer.config.ShareDir
+	ctx := context.Background()
+
+	require.NoError(t, installer.Install(ctx, InstallOptions{Repo: "github.com/owner/griptest"}))
+
+	expected := []string{
+		filepath.Join(shareDir, "man", "man1", "griptest.1"),
+		filepath.Join(shareDir, "bash-completion", "completions", "griptest"),
+		filepath.Join(shareDir, "zsh", "site-functions", "_griptest"),
+		filepath.Join(shareDir, "fish", "vendor_completions.d", "griptest.fish"),
+	}
+	for _, path := range expected {
+		assert.FileExists(t, path)
+	}
+	inst, err := installer.storage.Get("griptest")
+	require.NoError(t, err)
+	assert.ElementsMatch(t, expected, inst.ShareFiles)
+
+	require.NoError(t, installer.Remove("griptest"))
+	for _, path := range expected {
+		assert.NoFileExists(t, path)
+	}
+
+	t.Run("shared names", func(t *testing.T) {
+		archive := func(content string) []byte {
+			return gzipBytes(t, newTarStream(t, []tarEntry{
+				{name: "tool", content: elfBinary(elf.ELFCLASS64, elf.ELFDATA2LSB, elf.EM_X86_64, elf.ELFOSABI_NONE), mode: 0o755},
+				{name: "completions/tool.bash", content: []byte(content)},
+			}))
+		}
+		completion := filepath.Join(shareDir, "bash-completion", "completions", "tool")
+
+		gh, client := newTestRelease(t, archive("first"), "v1.0.0", "tool_linux_amd64.tar.gz")
+		installer.ghClient = gh
+		installer.httpClient = client
+		require.NoError(t, installer.Install(ctx, InstallOptions{Repo: "github.com/first/tool", Alias: "first"}))
+
+		gh, client = newTestRelease(t, archive("second"), "v1.0.0", "tool_linux_amd64.tar.gz")
+		installer.ghClient = gh
+		installer.httpClient = client
+		require.NoError(t, installer.Install(ctx, InstallOptions{Repo: "github.com/second/tool", Alias: "second"}))
+
+		got, err := os.ReadFile(completion)
+		require.NoError(t, err)
+		assert.Equal(t, "first", string(got), "not overwritten")
+		second, err := installer.storage.Get("second")
+		require.NoError(t, err)
+		assert.Empty(t, second.ShareFiles)
+
+		require.NoError(t, installer.Remove("second"))
+		assert.FileExists(t, completion)
+		require.NoError(t, installer.Remove("first"))
+		assert.NoFileExists(t, completion)
+	})
+
+	t.Run("existing files", func(t *testing.T) {
+		manPage := filepath.Join(shareDir, "man", "man1", "griptest.1")
+		require.NoError(t, os.MkdirAll(filepath.Dir(manPage), 0o755))
+		require.NoError(t, os.WriteFile(manPage, []byte("from the distribution"), 0o644))
+
+		installer.ghClient = gh
+		installer.httpClient = client
+		require.NoError(t, installer.Install(ctx, InstallOptions{Repo: "github.com/owner/griptest"}))
+		got, err := os.ReadFile(manPage)
+		require.NoError(t, err)
+		assert.Equal(t, "from the distribution", string(got), "not overwritten")
+		inst, err := installer.storage.Get("griptest")
+		require.NoError(t, err)
+		assert.NotContains(t, inst.ShareFiles, manPage)
+		assert.Len(t, inst.ShareFiles, 3)
+
+		// Files of the installation itself are replaced on reinstall
+		require.NoError(t, installer.Install(ctx, InstallOptions{Repo: "github.com/owner/griptest", Force: true}))
+		inst, err = installer.storage.Get("griptest")
+		require.NoError(t, err)
+		assert.Len(t, inst.ShareFiles, 3)
+
+		require.NoError(t, installer.Remove("griptest"))
+		assert.FileExists(t, manPage)
+	})
+}
+
+// TestInstallRawBinary tests installing executables published without archive
+func TestInstallRawBinary(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+	linux := Platform{OS: "linux", Arch: "amd64"}
+
+	t.Run("executable", func(t *testing.T) {
+		t.Parallel()
+
+		binary := elfBinary(elf.ELFCLASS64, elf.ELFDATA2LSB, elf.EM_X86_64, elf.ELFOSABI_NONE)
+		gh, client := newTestRelease(t, binary, "v1.0.0", "griptest-1.0.0-linux-amd64")
+		installer := newTestInstaller(t, gh, client, linux)
+
+		require.NoError(t, installer.Install(ctx, InstallOptions{Repo: "github.com/owner/griptest"}))
+		installed, err := os.ReadFile(filepath.Join(installer.config.BinDir, "griptest"))
+		require.NoError(t, err)
+		assert.Equal(t, binary, installed)
+	})
+
+	t.Run("appimage", func(t *testing.T) {
+		t.Parallel()
+
+		binary := elfBinary(elf.ELFCLASS64, elf.ELFDATA2LSB, elf.EM_X86_64, elf.ELFOSABI_NONE)
+		gh, client := newTestRelease(t, binary, "v1.0.0", "Griptest-1.0.0-x86_64.AppImage")
+		installer := newTestInstaller(t, gh, client, linux)
+
+		require.NoError(t, installer.Install(ctx, InstallOptions{Repo: "github.com/owner/griptest"}))
+		assert.FileExists(t, filepath.Join(installer.config.BinDir, "griptest"))
+	})
+
+	t.Run("not an executable", func(t *testing.T) {
+		t.Parallel()
+
+		gh, client := newTestRelease(t, []byte("<html>not found</html>"), "v1.0.0", "griptest-linux-amd64")
+		installer := newTestInstaller(t, gh, client, linux)
+
+		err := installer.Install(ctx, InstallOptions{Repo: "github.com/owner/griptest"})
+		assert.ErrorIs(t, err, ErrInvalidAsset)
+		assert.NoFileExists(t, filepath.Join(installer.config.BinDir, "griptest"))
+	})
+}
+
+// TestUnpackAssetSteps tests that the progress bars of raw binaries skip
+// the unpack step, unless their content is an archive
+func TestUnpackAssetSteps(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name    string
+		asset   string
+		content []byte
+		want    []string
+	}{
+		{"raw binary", "griptest-linux-amd64", elfBinary(elf.ELFCLASS64, elf.ELFDATA2LSB, elf.EM_X86_64, elf.ELFOSABI_NONE), rawSteps},
+		{"raw binary with archive content", "griptest-linux-amd64", linuxArchive(t), archiveSteps},
+		{"archive", "griptest-linux-amd64.tar.gz", linuxArchive(t), archiveSteps},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			dir := t.TempDir()
+			archivePath := filepath.Join(dir, tt.asset)
+			require.NoError(t, os.WriteFile(archivePath, tt.content, 0o644))
+
+			asset := &Asset{Name: tt.asset, RepoName: "griptest", OS: "linux", Arch: "amd64"}
+			files, err := unpackAsset(archivePath, filepath.Join(dir, "unpack"), asset, DefaultUnpackLimits())
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, files.steps)
+		})
+	}
+}
+
+// TestInstallWindows tests installing Windows executables for another target platform
+func TestInstallWindows(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+	linux := Platform{OS: "linux", Arch: "amd64"}
+	exe := peBinary(pe.IMAGE_FILE_MACHINE_AMD64, false)
+
+	t.Run("archive", func(t *testing.T) {
+		t.Parallel()
+
+		var buf bytes.Buffer
+		zw := zip.NewWriter(&buf)
+		for name, content := range map[string][]byte{
+			"griptest/griptest.exe":  exe,
+			"griptest/griptest.dll":  peBinary(pe.IMAGE_FILE_MACHINE_AMD64, true),
+			"griptest/griptest.conf": []byte("key = value"),
+		} {
+			w, err := zw.Create(name)
+			require.NoError(t, err)
+			_, err = w.Write(content)
+			require.NoError(t, err)
+		}
+		require.NoError(t, zw.Close())
+
+		gh, client := newTestRelease(t, buf.Bytes(), "v1.0.0", "griptest_linux_amd64.tar.gz", "griptest_windows_amd64.zip")
+		installer := newTestInstaller(t, gh, client, linux)
+		dest := filepath.Join(t.TempDir(), "windows")
+
+		require.NoError(t, installer.Install(ctx, InstallOptions{Repo: "github.com/owner/griptest", Destination: dest, OS: "windows", Arch: "amd64"}))
+		installed, err := os.ReadFile(filepath.Join(dest, "griptest.exe"))
+		require.NoError(t, err)
+		assert.Equal(t, exe, installed)
+	})
+
+	t.Run("binary name without exe", func(t *testing.T) {
+		t.Parallel()
+
+		var buf bytes.Buffer
+		zw := zip.NewWriter(&buf)
+		for _, name := range []string{"tool-1.0/tool.exe", "tool-1.0/helper.exe"} {
+			w, err := zw.Create(name)
+			require.NoError(t, err)
+			_, err = w.Write(exe)
+			require.NoError(t, err)
+		}
+		require.NoError(t, zw.Close())
+
+		gh, client := newTestRelease(t, buf.Bytes(), "v1.0.0", "griptest_windows_amd64.zip")
+		installer := newTestInstaller(t, gh, client, linux)
+		dest := filepath.Join(t.TempDir(), "windows")
+
+		require.NoError(t, installer.Install(ctx, InstallOptions{Repo: "github.com/owner/griptest", Destination: dest, OS: "windows", Arch: "amd64", Binary: "tool", Bins: []string{"helper"}}))
+		assert.FileExists(t, filepath.Join(dest, "tool.exe"))
+		assert.FileExists(t, filepath.Join(dest, "helper.exe"))
+	})
+
+	t.Run("raw binary", func(t *testing.T) {
+		t.Parallel()
+
+		gh, client := newTestRelease(t, exe, "v1.0.0", "griptest-linux-amd64", "griptest-windows-amd64.exe")
+		installer := newTestInstaller(t, gh, client, linux)
+		dest := filepath.Join(t.TempDir(), "windows")
+
+		require.NoError(t, installer.Install(ctx, InstallOptions{Repo: "github.com/owner/griptest", Destination: dest, OS: "windows", Alias: "gt"}))
+		assert.FileExists(t, filepath.Join(dest, "gt.exe"))
+	})
+
+	t.Run("wrong architecture", func(t *testing.T) {
+		t.Parallel()
+
+		gh, client := newTestRelease(t, exe, "v1.0.0", "griptest-windows-arm64.exe")
+		installer := newTestInstaller(t, gh, client, linux)
+
+		err := installer.Install(ctx, InstallOptions{Repo: "github.com/owner/griptest", Destination: t.TempDir(), OS: "windows", Arch: "arm64"})
+		assert.ErrorIs(t, err, ErrWrongPlatform)
+	})
+}
+
+// TestInstallCrossTarget tests installing for another platform than the host
+func TestInstallCrossTarget(t *testing.T) {
+	t.Parallel()
+
+	arm64 := elfBinary(elf.ELFCLASS64, elf.ELFDATA2LSB, elf.EM_AARCH64, elf.ELFOSABI_NONE)
+	archive := gzipBytes(t, newTarStream(t, []tarEntry{
+		{name: "griptest", content: arm64, mode: 0o755},
+		{name: "griptest.1", content: []byte(".TH GRIPTEST 1")},
+	}))
+	gh, client := newTestRelease(t, archive, "v1.0.0", "griptest_linux_amd64.tar.gz", "griptest_linux_arm64.tar.gz")
+	installer := newTestInstaller(t, gh, client, Platform{OS: "linux", Arch: "amd64"})
+	ctx := context.Background()
+
+	// The bin dir holds executables for the host
+	err := installer.Install(ctx, InstallOptions{Repo: "github.com/owner/griptest", Arch: "arm64"})
+	require.ErrorIs(t, err, ErrCrossTarget)
+
+	// The target platform drives the binary check
+	err = installer.Install(ctx, InstallOptions{Repo: "github.com/owner/griptest"})
+	require.ErrorIs(t, err, ErrWrongPlatform)
+
+	dest := filepath.Join(t.TempDir(), "image", "usr", "local", "bin")
+	require.NoError(t, installer.Install(ctx, InstallOptions{Repo: "github.com/owner/griptest", Destination: dest, Arch: "arm64"}))
+	assert.FileExists(t, filepath.Join(dest, "griptest"))
+
+	// It isn't recorded, doesn't install man pages for the host and
+	// doesn't conflict with installations for the host
+	list, err := installer.storage.List()
+	require.NoError(t, err)
+	assert.Empty(t, list)
+	assert.NoDirExists(t, installer.config.ShareDir)
+	require.NoError(t, installer.Install(ctx, InstallOptions{Repo: "github.com/owner/griptest", Destination: dest, Arch: "arm64"}))
+}
+
+// TestInfo tests the details of installed packages and repositories
+func TestInfo(t *testing.T) {
+	t.Parallel()
+
+	gh, client := newTestRelease(t, linuxArchive(t), "v1.0.0", "griptest_darwin_amd64.tar.gz", "griptest_linux_amd64.tar.gz")
+	gh.repo = &github.Repository{
+		Description: github.String("a tool for tests"),
+		License:     &github.License{SPDXID: github.String("MIT"), Name: github.String("MIT License")},
+	}
+	installer := newTestInstaller(t, gh, client, Platform{OS: "linux", Arch: "amd64"})
+	ctx := context.Background()
+	require.NoError(t, installer.Install(ctx, InstallOptions{Repo: "github.com/owner/griptest"}))
+
+	gh.release.TagName = github.String("v1.1.0")
+	gh.release.Body = github.String(strings.Repeat("- fix\n", 20))
+
+	info, err := installer.Info(ctx, "griptest")
+	require.NoError(t, err)
+	assert.Equal(t, "github.com/owner/griptest", info.Repo)
+	assert.Equal(t, "a tool for tests", info.Description)
+	assert.Equal(t, "MIT", info.License)
+	require.NotNil(t, info.Installed)
+	assert.Equal(t, "v1.0.0", info.Installed.Tag)
+	assert.NotEmpty(t, info.Installed.SHA256)
+	assert.True(t, info.UpdateAvailable)
+
+	require.NotNil(t, info.Latest)
+	assert.Equal(t, "v1.1.0", info.Latest.Tag)
+	assert.Equal(t, notesExcerptLines+1, strings.Count(info.Latest.Notes, "\n")+1)
+	assert.Equal(t, "linux/amd64", info.Latest.Platform)
+	assert.Equal(t, "griptest_linux_amd64.tar.gz", info.Latest.Selected)
+	require.Len(t, info.Latest.Assets, 2)
+	assert.Equal(t, "griptest_linux_amd64.tar.gz", info.Latest.Assets[0].Name)
+	assert.Contains(t, info.Latest.Assets[0].Reasons, "os linux +40")
+	assert.Equal(t, 0, info.Latest.Assets[1].Score)
+
+	// Repositories need not be installed
+	info, err = installer.Info(ctx, "github.com/owner/other")
+	require.NoError(t, err)
+	assert.Nil(t, info.Installed)
+	assert.Equal(t, "v1.1.0", info.Latest.Tag)
+
+	_, err = installer.Info(ctx, "other")
+	assert.ErrorIs(t, err, ErrInvalidRepo)
+
+	// Installations are shown without GitHub
+	gh.repo = nil
+	info, err = installer.Info(ctx, "griptest")
+	require.NoError(t, err)
+	assert.NotNil(t, info.Installed)
+	assert.Nil(t, info.Latest)
+}
+
+// gzipBytes compresses the content of r
+func gzipBytes(t testing.TB, r io.Reader) []byte {
+	t.Helper()
+	var buf bytes.Buffer
+	gw := gzip.NewWriter(&buf)
+	_, err := io.Copy(gw, r)
+	require.NoError(t, err)
+	require.NoError(t, gw.Close())
+	return buf.Bytes()
+}
+
+// TestInstallAssetPattern tests asset patterns, binaries and package specs
+func TestInstallAssetPattern(t *testing.T) {
+	t.Parallel()
+
+	// The helper would be detected as the executable without a binary name
+	archive := gzipBytes(t, newTarStream(t, []tarEntry{
+		{name: "tool-1.0/a-helper", content: machOBinary(), mode: 0o755},
+		{name: "tool-1.0/bin/tl", content: []byte("#!/bin/sh\necho tool\n"), mode: 0o755},
+	}))
+	ctx := context.Background()
+	linux := Platform{OS: "linux", Arch: "amd64"}
+
+	t.Run("options are persisted and reused by update", func(t *testing.T) {
+		t.Parallel()
+
+		gh, client := newTestRelease(t, archive, "v1.0.0", "tool_linux_amd64.tar.gz", "tool-static-1.0.0.tar.gz")
+		installer := newTestInstaller(t, gh, client, linux)
+
+		require.NoError(t, installer.Install(ctx, InstallOptions{
+			Repo:         "github.com/owner/griptest",
+			AssetPattern: "tool-static-{{version}}.tar.gz",
+			Binary:       "bin/tl",
+		}))
+		assert.FileExists(t, filepath.Join(installer.config.BinDir, "tl"))
+
+		inst, err := installer.storage.Get("tl")
+		require.NoError(t, err)
+		assert.Equal(t, "tool-static-{{version}}.tar.gz", inst.AssetPattern)
+		assert.Equal(t, "bin/tl", inst.Binary)
+
+		// Only the pattern can select the asset of the new release
+		gh.release.TagName = github.String("v1.1.0")
+		gh.release.Assets[1].Name = github.String("tool-static-1.1.0.tar.gz")
+		gh.release.Assets = gh.release.Assets[1:]
+		require.NoError(t, installer.Update(ctx, "tl"))
+
+		inst, err = installer.storage.Get("tl")
+		require.NoError(t, err)
+		assert.Equal(t, "v1.1.0", inst.Tag)
+	})
+
+	t.Run("package spec", func(t *testing.T) {
+		t.Parallel()
+
+		gh, client := newTestRelease(t, archive, "v1.0.0", "tool_linux_amd64.tar.gz")
+		installer := newTestInstaller(t, gh, client, linux)
+		installer.config.Packages = map[string]PackageSpec{"github.com/owner/griptest": {Binary: "tl"}}
+
+		require.NoError(t, installer.Install(ctx, InstallOptions{Repo: "github.com/Owner/griptest"}))
+		inst, err := installer.storage.Get("tl")
+		require.NoError(t, err)
+		assert.Equal(t, "tl", inst.Binary)
+	})
+
+	t.Run("missing binary", func(t *testing.T) {
+		t.Parallel()
+
+		gh, client := newTestRelease(t, archive, "v1.0.0", "tool_linux_amd64.tar.gz")
+		installer := newTestInstaller(t, gh, client, linux)
+
+		err := installer.Install(ctx, InstallOptions{Repo: "github.com/owner/griptest", Binary: "nope"})
+		assert.ErrorIs(t, err, ErrNotFound)
+	})
+}
+
+// TestInstallPickAsset tests choosing between ambiguous assets
+func TestInstallPickAsset(t *testing.T) {
+	t.Parallel()
+
+	archive := linuxArchive(t)
+	ctx := context.Background()
+	linux := Platform{OS: "linux", Arch: "amd64"}
+
+	t.Run("choice is remembered as pattern", func(t *testing.T) {
+		t.Parallel()
+
+		gh, client := newTestRelease(t, archive, "v1.2.0", "tool-1.2.0-linux-amd64-gnu.tar.gz", "tool-1.2.0-linux-amd64-musl.tar.gz")
+		installer := newTestInstaller(t, gh, client, linux)
+		installer.stdin = strings.NewReader("7\n2\n")
+
+		require.NoError(t, installer.Install(ctx, InstallOptions{Repo: "github.com/owner/griptest", Interactive: true}))
+
+		inst, err := installer.storage.Get("griptest")
+		require.NoError(t, err)
+		assert.Equal(t, "tool-{{version}}-{{os}}-{{arch}}-musl.tar.gz", inst.AssetPattern)
+	})
+
+	t.Run("pick when nothing matches", func(t *testing.T) {
+		t.Parallel()
+
+		gh, client := newTestRelease(t, archive, "v1.2.0", "tool-linux64.tar.gz", "tool-linux64.tar.gz.sha256")
+		installer := newTestInstaller(t, gh, client, linux)
+		installer.stdin = strings.NewReader("\n")
+
+		require.NoError(t, installer.Install(ctx, InstallOptions{Repo: "github.com/owner/griptest", Interactive: true}))
+		inst, err := installer.storage.Get("griptest")
+		require.NoError(t, err)
+		assert.Equal(t, "tool-linux64.tar.gz", inst.AssetPattern)
+	})
+
+	t.Run("non-interactive error lists candidates", func(t *testing.T) {
+		t.Parallel()
+
+		gh, client := newTestRelease(t, archive, "v1.2.0", "tool-linux64.tar.gz", "tool-linux64.tar.gz.sha256", "tool-win64.zip")
+		installer := newTestInstaller(t, gh, client, linux)
+
+		err := installer.Install(ctx, InstallOptions{Repo: "github.com/owner/griptest"})
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "no asset found for linux_amd64, candidates:")
+		assert.Contains(t, err.Error(), "tool-linux64.tar.gz (")
+		assert.Contains(t, err.Error(), "tool-win64.zip (")
+		assert.NotContains(t, err.Error(), ".sha256")
+	})
+
+	t.Run("derive pattern", func(t *testing.T) {
+		t.Parallel()
+
+		cfg := &Config{OS: "darwin", Arch: "arm64", OSAliases: map[string][]string{"darwin": {"macos"}}}
+		assert.Equal(t, "tool_{{version}}_{{os}}_{{arch}}.tar.gz", derivePattern("tool_v2.0.1_macos_arm64.tar.gz", cfg, "v2.0.1"))
+		assert.Equal(t, "tool-[[]full]-{{os}}.zip", derivePattern("tool-[full]-darwin.zip", cfg, "v1.0.0"))
+	})
+}
+
+// TestSearch tests marking search results with a release for the platform
+func TestSearch(t *testing.T) {
+	t.Parallel()
+
+	repo := func(name string, stars int) *github.Repository {
+		return &github.Repository{
+			Name:            github.String(name),
+			Owner:           &github.User{Login: github.String("owner")},
+			Description:     github.String(name + " tool"),
+			StargazersCount: github.Int(stars),
+		}
+	}
+	gh := &fakeGitHubClient{
+		found: []*github.Repository{repo("linuxtool", 100), repo("mactool", 50), repo("lib", 10), repo("patterned", 5), repo("limited", 1)},
+		releases: map[string]*github.RepositoryRelease{
+			"linuxtool": {TagName: github.String("v1.0.0"), Assets: []*github.ReleaseAsset{
+				{Name: github.String("linuxtool_darwin_amd64.tar.gz")},
+				{Name: github.String("linuxtool_linux_amd64.tar.gz")},
+			}},
+			"mactool": {TagName: github.String("v2.0.0"), Assets: []*github.ReleaseAsset{
+				{Name: github.String("mactool_darwin_arm64.tar.gz")},
+			}},
+			"lib": nil,
+			"patterned": {TagName: github.String("v3.0.0"), Assets: []*github.ReleaseAsset{
+				{Name: github.String("patterned_linux_amd64.tar.gz")},
+				{Name: github.String("patterned-linux-amd64-static.zip")},
+			}},
+		},
+		errs: map[string]error{"limited": errors.New("403 API rate limit exceeded")},
+	}
+	installer := newTestInstaller(t, gh, http.DefaultClient, Platform{OS: "linux", Arch: "amd64"})
+	installer.config.Packages = map[string]PackageSpec{"github.com/owner/patterned": {AssetPattern: "patterned-{{os}}-{{arch}}-static.zip"}}
+
+	results, err := installer.Search(context.Background(), "tool", 10)
+	require.NoError(t, err)
+	require.Len(t, results, 5)
+
+	assert.Equal(t, "github.com/owner/linuxtool", results[0].Repo)
+	assert.Equal(t, 100, results[0].Stars)
+	assert.Equal(t, "v1.0.0", results[0].Tag)
+	assert.Equal(t, "linuxtool_linux_amd64.tar.gz", results[0].Asset)
+	assert.True(t, results[0].Installable())
+
+	assert.Equal(t, "v2.0.0", results[1].Tag)
+	assert.False(t, results[1].Installable())
+
+	assert.Equal(t, "lib tool", results[2].Description)
+	assert.Empty(t, results[2].Tag)
+	assert.False(t, results[2].Installable())
+
+	assert.Equal(t, "patterned-linux-amd64-static.zip", results[3].Asset, "selected by the package spec")
+
+	assert.Contains(t, results[4].Error, "rate limit")
+	assert.Empty(t, results[4].Tag)
+	assert.False(t, results[4].Installable())
+
+	results, err = installer.Search(context.Background(), "tool", 1)
+	require.NoError(t, err)
+	assert.Len(t, results, 1)
+}
@@ -0,0 +1,170 @@
+package grip
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// layout holds the directories grip stores its data in.
+//
+// With GRIP_HOME set, everything lives below it, like in the legacy ~/.grip
+// layout. Otherwise grip follows the XDG Base Directory specification:
+//
+//	data  $XDG_DATA_HOME/grip  (~/.local/share/grip), binaries in bin/,
+//	      man pages and shell completions in share/
+//	state $XDG_STATE_HOME/grip (~/.local/state/grip), installation database
+//	cache $XDG_CACHE_HOME/grip (~/.cache/grip), download workspaces
+type layout struct {
+	DataDir  string
+	BinDir   string
+	StateDir string
+	CacheDir string
+}
+
+// resolveLayout determines the directories for the user with the given home
+func resolveLayout(home string, getenv func(string) string) layout {
+	if gripHome := getenv("GRIP_HOME"); gripHome != "" {
+		return layout{
+			DataDir:  gripHome,
+			BinDir:   filepath.Join(gripHome, "bin"),
+			StateDir: gripHome,
+			CacheDir: filepath.Join(gripHome, "cache"),
+		}
+	}
+
+	dataDir := filepath.Join(xdgDir(getenv, "XDG_DATA_HOME", home, ".local/share"), "grip")
+	return layout{
+		DataDir:  dataDir,
+		BinDir:   filepath.Join(dataDir, "bin"),
+		StateDir: filepath.Join(xdgDir(getenv, "XDG_STATE_HOME", home, ".local/state"), "grip"),
+		CacheDir: filepath.Join(xdgDir(getenv, "XDG_CACHE_HOME", home, ".cache"), "grip"),
+	}
+}
+
+// xdgDir returns the directory from the environment variable key, or
+// fallback below home. Relative values are ignored, as required by the spec.
+func xdgDir(getenv func(string) string, key, home, fallback string) string {
+	if dir := getenv(key); filepath.IsAbs(dir) {
+		return dir
+	}
+	return filepath.Join(home, filepath.FromSlash(fallback))
+}
+
+// MigrateLegacyHome moves an existing ~/.grip layout into the configured
+// directories and rewrites the install paths of moved binaries.
+// It reports whether anything was migrated; nothing happens when the legacy
+// directory doesn't exist or the installation database was already created.
+func MigrateLegacyHome(cfg *Config) (bool, error) {
+	legacy := cfg.LegacyHomeDir
+	if legacy == "" || legacy == cfg.HomeDir {
+		return false, nil
+	}
+	if _, err := os.Stat(legacy); err != nil {
+		return false, nil
+	}
+	if _, err := os.Stat(cfg.StorePath); err == nil {
+		return false, nil
+	}
+
+	if err := cfg.EnsureDirs(); err != nil {
+		return false, err
+	}
+
+	// Binaries
+	legacyBin := filepath.Join(legacy, "bin")
+	entries, err := os.ReadDir(legacyBin)
+	if err != nil && !errors.Is(err, os.ErrNotExist) {
+		return false, fmt.Errorf("read legacy bin directory: %w", err)
+	}
+	for _, e := range entries {
+		dst := filepath.Join(cfg.BinDir, e.Name())
+		if _, err := os.Lstat(dst); err == nil {
+			return false, fmt.Errorf("migrate %s: %w", dst, ErrAlreadyExists)
+		}
+		if err := moveFile(filepath.Join(legacyBin, e.Name()), dst); err != nil {
+			return false, fmt.Errorf("migrate %s: %w", e.Name(), err)
+		}
+	}
+
+	// Installation database
+	legacyStore := filepath.Join(legacy, "grip.json")
+	if _, err := os.Stat(legacyStore); err == nil {
+		data, err := (&Storage{filepath: legacyStore}).load()
+		if err != nil {
+			return false, fmt.Errorf("read legacy storage: %w", err)
+		}
+		for _, inst := range data {
+			if filepath.Clean(inst.InstallPath) == legacyBin {
+				inst.InstallPath = cfg.BinDir
+			}
+		}
+		if err := (&Storage{filepath: cfg.StorePath}).save(data); err != nil {
+			return false, fmt.Errorf("write storage: %w", err)
+		}
+		if err := os.Remove(legacyStore); err != nil {
+			return false, err
+		}
+	}
+
+	// Old lock files are picked up by NewStorage from the data directory
+	for _, name := range []string{"grip.lock", "grip.lock.backup"} {
+		src := filepath.Join(legacy, name)
+		content, err := os.ReadFile(src)
+		if err != nil {
+			continue
+		}
+		content = []byte(strings.ReplaceAll(string(content), legacyBin, cfg.BinDir))
+		if err := os.WriteFile(filepath.Join(cfg.HomeDir, name), content, 0644); err != nil {
+			return false, err
+		}
+		if err := os.Remove(src); err != nil {
+			return false, err
+		}
+	}
+
+	// Only succeeds when nothing else is left behind
+	_ = os.Remove(legacyBin)
+	_ = os.Remove(legacy)
+
+	return true, nil
+}
+
+// moveFile renames src to dst and falls back to copying when they are on
+// different file systems
+func moveFile(src, dst string) error {
+	if err := os.Rename(src, dst); err == nil {
+		return nil
+	}
+
+	info, err := os.Stat(src)
+	if err != nil {
+		return err
+	}
+	if info.IsDir() {
+		return fmt.Errorf("cannot move directory %s across file systems", src)
+	}
+
+	in, err := os.Open(src)
+	if err != nil {
+		return err
+	}
+	defer in.Close()
+
+	out, err := os.OpenFile(dst, os.O_CREATE|os.O_WRONLY|os.O_EXCL, info.Mode().Perm())
+	if err != nil {
+		return err
+	}
+	if _, err := io.Copy(out, in); err != nil {
+		out.Close()
+		return err
+	}
+	if err := out.Close(); err != nil {
+		return err
+	}
+
+	return os.Remove(src)
+}
@@ -0,0 +1,69 @@
+package grip
+
+import (
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"strings"
+)
+
+// C libraries of Linux hosts
+const (
+	LibcGNU  = "gnu"
+	LibcMusl = "musl"
+)
+
+// detectLibc returns the C library of the host, or an empty string when it
+// isn't Linux or the library can't be determined
+func detectLibc() string {
+	if runtime.GOOS != "linux" {
+		return ""
+	}
+	return detectLibcFrom(filepath.Glob, func() (string, error) {
+		// ldd of musl prints its version to stderr and exits with 1
+		out, _ := exec.Command("ldd", "--version").CombinedOutput()
+		return string(out), nil
+	})
+}
+
+// detectLibcFrom checks the dynamic loaders present and falls back to the
+// output of ldd --version
+func detectLibcFrom(glob func(string) ([]string, error), ldd func() (string, error)) string {
+	if matches, _ := glob("/lib/ld-musl-*.so.1"); len(matches) > 0 {
+		return LibcMusl
+	}
+	for _, pattern := range []string{"/lib64/ld-linux-*.so.*", "/lib/ld-linux*.so.*", "/lib/*-linux-gnu*/ld-linux*.so.*"} {
+		if matches, _ := glob(pattern); len(matches) > 0 {
+			return LibcGNU
+		}
+	}
+
+	out, err := ldd()
+	if err != nil {
+		return ""
+	}
+	out = strings.ToLower(out)
+	switch {
+	case strings.Contains(out, "musl"):
+		return LibcMusl
+	case strings.Contains(out, "glibc"), strings.Contains(out, "gnu libc"):
+		return LibcGNU
+	}
+	return ""
+}
+
+// libcOf returns the C library an asset is built for, from its tokens:
+// "gnu", "musl", "static" or an empty string if it isn't marked
+func libcOf(tokens []string) string {
+	for _, t := range tokens {
+		switch {
+		case t == "static":
+			return "static"
+		case strings.HasPrefix(t, "musl"): // musl, musleabihf
+			return LibcMusl
+		case t == "glibc", strings.HasPrefix(t, "gnu"): // gnu, gnueabihf
+			return LibcGNU
+		}
+	}
+	return ""
+}
@@ -0,0 +1,51 @@
+package grip
+
+import (
+	"maps"
+	"path"
+	"strings"
+)
+
+// PackageSpec overrides the asset selection of a repository
+type PackageSpec struct {
+	AssetPattern string `toml:"asset_pattern,omitempty"` // see compileAssetPattern
+	Binary       string `toml:"binary,omitempty"`        // file to install from the archive
+}
+
+// knownPackages holds specs for popular repositories whose executable isn't
+// named after the repository. Only tools that work as a single executable
+// belong here, not those needing files shipped next to it, like editors with
+// a runtime directory. Keys are lowercase repository paths.
+var knownPackages = map[string]PackageSpec{
+	"github.com/burntsushi/ripgrep":  {Binary: "rg"},
+	"github.com/cli/cli":             {Binary: "gh"},
+	"github.com/clementtsang/bottom": {Binary: "btm"},
+	"github.com/nushell/nushell":     {Binary: "nu"},
+	"github.com/tldr-pages/tlrc":     {Binary: "tldr"},
+	"github.com/wilfred/difftastic":  {Binary: "difft"},
+}
+
+// defaultPackages returns a copy of the built-in package specs
+func defaultPackages() map[string]PackageSpec {
+	return maps.Clone(knownPackages)
+}
+
+// packageKey normalizes a repository path for lookups in Config.Packages
+func packageKey(repo string) string {
+	owner, name, err := ParseRepoPath(repo)
+	if err != nil {
+		return strings.ToLower(repo)
+	}
+	return strings.ToLower("github.com/" + owner + "/" + name)
+}
+
+// PackageSpec returns the spec configured or built in for repo
+func (c *Config) PackageSpec(repo string) (PackageSpec, bool) {
+	spec, ok := c.Packages[packageKey(repo)]
+	return spec, ok
+}
+
+// binaryBase returns the installed name of a binary path inside an archive
+func binaryBase(binary string) string {
+	return path.Base(strings.ReplaceAll(binary, "\\", "/"))
+}
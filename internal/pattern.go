@@ -0,0 +1,96 @@
+package grip
+
+import (
+	"fmt"
+	"path"
+	"regexp"
+	"slices"
+	"strings"
+)
+
+// regexPatternPrefix marks an asset pattern as regular expression
+const regexPatternPrefix = "re:"
+
+var placeholderRe = regexp.MustCompile(`\{\{\s*(\w+)\s*\}\}`)
+
+// compileAssetPattern compiles a user supplied asset pattern into a matcher.
+// Patterns are case insensitive globs, or regular expressions when prefixed
+// with "re:". The placeholders {{os}}, {{arch}} and {{version}} match the
+// target OS and arch (or their aliases) and the release tag with or without
+// a leading "v".
+func compileAssetPattern(pattern string, cfg *Config, tag string) (func(string) bool, error) {
+	values := map[string][]string{
+		"os":      append([]string{cfg.OS}, cfg.OSAliases[cfg.OS]...),
+		"arch":    append([]string{cfg.Arch}, cfg.ArchAliases[cfg.Arch]...),
+		"version": uniqueStrings(tag, strings.TrimPrefix(tag, "v")),
+	}
+	for _, m := range placeholderRe.FindAllStringSubmatch(pattern, -1) {
+		if _, ok := values[strings.ToLower(m[1])]; !ok {
+			return nil, fmt.Errorf("unknown placeholder %s in asset pattern, valid: {{os}}, {{arch}}, {{version}}", m[0])
+		}
+	}
+
+	if expr, ok := strings.CutPrefix(pattern, regexPatternPrefix); ok {
+		expr = placeholderRe.ReplaceAllStringFunc(expr, func(p string) string {
+			alternatives := values[strings.ToLower(placeholderRe.FindStringSubmatch(p)[1])]
+			quoted := make([]string, len(alternatives))
+			for i, a := range alternatives {
+				quoted[i] = regexp.QuoteMeta(a)
+			}
+			return "(?:" + strings.Join(quoted, "|") + ")"
+		})
+		re, err := regexp.Compile("(?i)" + expr)
+		if err != nil {
+			return nil, fmt.Errorf("invalid asset pattern: %w", err)
+		}
+		return re.MatchString, nil
+	}
+
+	globs := expandPlaceholders(strings.ToLower(pattern), values)
+	for _, g := range globs {
+		if _, err := path.Match(g, ""); err != nil {
+			return nil, fmt.Errorf("invalid asset pattern %q: %w", pattern, err)
+		}
+	}
+	return func(name string) bool {
+		name = strings.ToLower(name)
+		for _, g := range globs {
+			if ok, _ := path.Match(g, name); ok {
+				return true
+			}
+		}
+		return false
+	}, nil
+}
+
+// ValidateAssetPattern checks the syntax of an asset pattern
+func ValidateAssetPattern(pattern string) error {
+	_, err := compileAssetPattern(pattern, &Config{OS: "os", Arch: "arch"}, "v0.0.0")
+	return err
+}
+
+// expandPlaceholders returns every combination of placeholder values in pattern
+func expandPlaceholders(pattern string, values map[string][]string) []string {
+	m := placeholderRe.FindStringSubmatchIndex(pattern)
+	if m == nil {
+		return []string{pattern}
+	}
+
+	var result []string
+	for _, v := range values[pattern[m[2]:m[3]]] {
+		expanded := pattern[:m[0]] + strings.ToLower(v) + pattern[m[1]:]
+		result = append(result, expandPlaceholders(expanded, values)...)
+	}
+	return result
+}
+
+// uniqueStrings returns the non-empty values without duplicates
+func uniqueStrings(values ...string) []string {
+	var result []string
+	for _, v := range values {
+		if v != "" && !slices.Contains(result, v) {
+			result = append(result, v)
+		}
+	}
+	return result
+}
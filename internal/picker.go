@@ -0,0 +1,111 @@
+package grip
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/alexjoedt/grip/internal/logger"
+)
+
+// pickFn lets the user choose one of the candidates
+type pickFn func(candidates []assetCandidate) (assetCandidate, error)
+
+// promptAsset returns a pickFn showing a numbered list of the candidates
+// and reading the choice from in. An empty answer selects the first one.
+func promptAsset(in io.Reader) pickFn {
+	reader := bufio.NewReader(in)
+	return func(candidates []assetCandidate) (assetCandidate, error) {
+		logger.Println("Select the asset to install:")
+		for n, c := range candidates {
+			logger.Println("  %2d) %s (%s)", n+1, c.name, formatSize(int64(c.asset.GetSize())))
+		}
+
+		for {
+			logger.Print("Asset [1-%d, default 1]: ", len(candidates))
+			input, err := reader.ReadString('\n')
+			input = strings.TrimSpace(input)
+			if input == "" && err != nil {
+				return assetCandidate{}, fmt.Errorf("no asset selected")
+			}
+			if input == "" {
+				return candidates[0], nil
+			}
+
+			n, convErr := strconv.Atoi(input)
+			if convErr == nil && n >= 1 && n <= len(candidates) {
+				return candidates[n-1], nil
+			}
+			if err != nil {
+				return assetCandidate{}, fmt.Errorf("invalid choice %q", input)
+			}
+			logger.Warn("Invalid choice %q", input)
+		}
+	}
+}
+
+// candidateList formats assets for an error message
+func candidateList(candidates []assetCandidate) string {
+	var b strings.Builder
+	for _, c := range candidates {
+		fmt.Fprintf(&b, "\n  %s (%s)", c.name, formatSize(int64(c.asset.GetSize())))
+	}
+	return b.String()
+}
+
+// derivePattern turns a chosen asset name into an asset pattern for later
+// releases by replacing the version, OS and arch with placeholders
+func derivePattern(name string, cfg *Config, tag string) string {
+	pattern := strings.NewReplacer("*", "[*]", "?", "[?]", "[", "[[]").Replace(name)
+
+	for _, v := range []string{tag, strings.TrimPrefix(tag, "v")} {
+		if v != "" {
+			pattern = replaceToken(pattern, strings.ToLower(v), "{{version}}")
+		}
+	}
+	for _, os := range append([]string{cfg.OS}, cfg.OSAliases[cfg.OS]...) {
+		pattern = replaceToken(pattern, os, "{{os}}")
+	}
+	for _, arch := range append([]string{cfg.Arch}, cfg.ArchAliases[cfg.Arch]...) {
+		pattern = replaceToken(pattern, arch, "{{arch}}")
+	}
+
+	// Fall back to the literal name if a replacement broke the pattern
+	if match, err := compileAssetPattern(pattern, cfg, tag); err != nil || !match(name) {
+		return strings.NewReplacer("*", "[*]", "?", "[?]", "[", "[[]").Replace(name)
+	}
+	return pattern
+}
+
+// replaceToken replaces token in s where it is delimited by separators
+func replaceToken(s, token, replacement string) string {
+	if token == "" {
+		return s
+	}
+	re := regexp.MustCompile(`(^|[-_. ])` + regexp.QuoteMeta(token) + `($|[-_. ])`)
+	// Matches can't overlap at a shared separator, so repeat until stable
+	for {
+		replaced := re.ReplaceAllString(s, "${1}"+replacement+"${2}")
+		if replaced == s {
+			return s
+		}
+		s = replaced
+	}
+}
+
+// formatSize formats a byte count for humans
+func formatSize(size int64) string {
+	const unit = 1024
+	if size < unit {
+		return fmt.Sprintf("%d B", size)
+	}
+	div, exp := int64(unit), 0
+	for n := size / unit; n >= unit; n /= unit {
+		div *= unit
+		exp++
+	}
+	return fmt.Sprintf("%.1f %ciB", float64(size)/float64(div), "KMGTPE"[exp])
+}
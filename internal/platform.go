@@ -1,41 +1,50 @@
 package grip
 
 import (
+	"fmt"
 	"net/url"
 	"strings"
 )
 
-// MatchesPlatform checks if filename contains given OS and Arch (or aliases)
-func MatchesPlatform(filename, targetOS, targetArch string, osAliases, archAliases map[string][]string) bool {
-	name := strings.ToLower(filename)
+// Platform is a target operating system and architecture pair
+type Platform struct {
+	OS   string
+	Arch string
+}
 
-	// Check OS
-	osMatches := strings.Contains(name, targetOS)
-	if !osMatches {
-		if aliases, ok := osAliases[targetOS]; ok {
-			for _, alias := range aliases {
-				if strings.Contains(name, alias) {
-					osMatches = true
-					break
-				}
-			}
-		}
+// ParsePlatform parses a platform in the form os/arch, e.g. linux/amd64
+func ParsePlatform(s string) (Platform, error) {
+	goos, arch, ok := strings.Cut(strings.ToLower(strings.TrimSpace(s)), "/")
+	if !ok || goos == "" || arch == "" || strings.Contains(arch, "/") {
+		return Platform{}, fmt.Errorf("invalid platform %q, use: os/arch", s)
 	}
+	return Platform{OS: goos, Arch: arch}, nil
+}
 
-	// Check Arch
-	archMatches := strings.Contains(name, targetArch)
-	if !archMatches {
-		if aliases, ok := archAliases[targetArch]; ok {
-			for _, alias := range aliases {
-				if strings.Contains(name, alias) {
-					archMatches = true
-					break
-				}
-			}
-		}
+// Override returns the platform with the operating system and architecture
+// replaced by goos and arch, unless they are empty
+func (p Platform) Override(goos, arch string) Platform {
+	if goos != "" {
+		p.OS = strings.ToLower(goos)
 	}
+	if arch != "" {
+		p.Arch = strings.ToLower(arch)
+	}
+	return p
+}
 
-	return osMatches && archMatches
+// String returns the platform in the form os/arch
+func (p Platform) String() string {
+	return p.OS + "/" + p.Arch
+}
+
+// MatchesPlatform checks if filename contains the given OS and Arch (or
+// aliases) as separate tokens, see tokenize
+func MatchesPlatform(filename, targetOS, targetArch string, osAliases, archAliases map[string][]string) bool {
+	tokens := tokenize(filename)
+	osScore, _ := matchToken(tokens, targetOS, osAliases[targetOS])
+	archScore, _ := matchToken(tokens, targetArch, archAliases[targetArch])
+	return osScore > 0 && archScore > 0
 }
 
 // ParseRepoPath extracts owner and repo name from various GitHub URL formats:
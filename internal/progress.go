@@ -1,10 +1,38 @@
 package grip
 
 import (
+	"fmt"
+	"slices"
+
 	"github.com/k0kubun/go-ansi"
 	"github.com/schollz/progressbar/v3"
 )
 
+// Steps of an installation, numbered in the progress bars
+const (
+	stepDownload = "Downloading"
+	stepUnpack   = "Unpacking"
+	stepInstall  = "Installing"
+)
+
+var (
+	archiveSteps = []string{stepDownload, stepUnpack, stepInstall}
+	rawSteps     = []string{stepDownload, stepInstall} // raw binaries aren't unpacked
+)
+
+// stepLabel returns the progress bar description of step, e.g. "[2/3] Unpacking"
+func stepLabel(steps []string, step string) string {
+	return fmt.Sprintf("[cyan][%d/%d][reset] %s", slices.Index(steps, step)+1, len(steps), step)
+}
+
+// assetSteps returns the installation steps of the named asset
+func assetSteps(name string) []string {
+	if isRawBinary(name) {
+		return rawSteps
+	}
+	return archiveSteps
+}
+
 func NewProgressBar(size int, description string) *progressbar.ProgressBar {
 	return progressbar.NewOptions(size,
 		progressbar.OptionFullWidth(),
@@ -0,0 +1,199 @@
+package grip
+
+import (
+	"fmt"
+	"slices"
+	"sort"
+	"strings"
+
+	"github.com/google/go-github/v56/github"
+)
+
+// Scores of the asset ranking, see rankAssets
+const (
+	scoreExact   = 40  // exact OS or arch token, e.g. "linux", "amd64"
+	scoreAlias   = 25  // alias token, e.g. "macos", "x86_64"
+	scoreArchive = 10  // supported archive format
+	scoreRaw     = 5   // executable without archive, e.g. tool-linux-amd64
+	scorePackage = 2   // deb, rpm or AppImage, when nothing else fits
+	scoreRepo    = 5   // name starts with the repository name
+	scoreDebug   = -30 // debug builds
+
+	scoreLibc      = 10  // built for the libc of the host
+	scoreStatic    = 8   // statically linked
+	scoreMuslOnGNU = 4   // musl builds are mostly static and run on glibc hosts
+	scoreGNUOnMusl = -20 // glibc builds fail on musl hosts with "not found"
+)
+
+// nonBinaryTokens mark assets that never contain an installable executable
+var nonBinaryTokens = map[string]bool{
+	"sbom": true, "spdx": true, "cyclonedx": true, "intoto": true, "provenance": true,
+	"sig": true, "asc": true, "pem": true, "crt": true, "cert": true, "pub": true, "minisig": true,
+	"sha1": true, "sha256": true, "sha256sum": true, "sha256sums": true, "sha512": true, "md5": true,
+	"checksum": true, "checksums": true, "txt": true, "json": true,
+	"src": true, "source": true, "vendor": true,
+	"license": true, "readme": true, "changelog": true,
+	"apk": true, "msi": true, "pkg": true, "dmg": true,
+}
+
+// debugTokens mark debug builds, which are only used as a last resort
+var debugTokens = map[string]bool{"debug": true, "dbg": true, "symbols": true}
+
+// assetCandidate is a release asset with its ranking
+type assetCandidate struct {
+	asset   *github.ReleaseAsset
+	name    string // lowercased asset name
+	score   int    // 0 or less if the asset can't be installed
+	reasons []string
+}
+
+// String explains the ranking of the candidate
+func (c assetCandidate) String() string {
+	return fmt.Sprintf("%4d %s (%s)", c.score, c.name, strings.Join(c.reasons, ", "))
+}
+
+// tokenize splits an asset name into lowercase tokens on "-", "_", "." and
+// spaces. "x86" followed by "64" is joined to "x86_64".
+func tokenize(name string) []string {
+	fields := strings.FieldsFunc(strings.ToLower(name), func(r rune) bool {
+		return r == '-' || r == '_' || r == '.' || r == ' '
+	})
+
+	tokens := make([]string, 0, len(fields))
+	for i := 0; i < len(fields); i++ {
+		if fields[i] == "x86" && i+1 < len(fields) && fields[i+1] == "64" {
+			tokens = append(tokens, "x86_64")
+			i++
+			continue
+		}
+		tokens = append(tokens, fields[i])
+	}
+	return tokens
+}
+
+// matchToken scores how tokens match value or one of its aliases. Aliases
+// are in order of preference, each scoring one less than the one before. A
+// value listed among its aliases ranks at that position, e.g. "arm" below
+// "armv7".
+func matchToken(tokens []string, value string, aliases []string) (int, string) {
+	if !slices.Contains(aliases, value) && slices.Contains(tokens, value) {
+		return scoreExact, value
+	}
+	for i, alias := range aliases {
+		if slices.Contains(tokens, alias) {
+			return max(scoreAlias-i, 1), alias
+		}
+	}
+	return 0, ""
+}
+
+// rankAsset scores a single asset name for the platform of cfg
+func rankAsset(name string, cfg *Config, repoName string) (int, []string) {
+	tokens := tokenize(name)
+
+	for _, t := range tokens {
+		if nonBinaryTokens[t] {
+			return 0, []string{"not a binary: " + t}
+		}
+	}
+
+	osScore, osToken := matchToken(tokens, cfg.OS, cfg.OSAliases[cfg.OS])
+	if osScore == 0 && cfg.OS == "linux" && isLinuxPackage(name) {
+		// Linux packages rarely name the OS, e.g. tool_1.0_amd64.deb
+		osScore, osToken = scoreAlias, "package"
+	}
+	if osScore == 0 {
+		return 0, []string{"no " + cfg.OS + " token"}
+	}
+	archScore, archToken := matchToken(tokens, cfg.Arch, cfg.ArchAliases[cfg.Arch])
+	if archScore == 0 {
+		return 0, []string{"no " + cfg.Arch + " token"}
+	}
+	score := osScore + archScore
+	reasons := []string{
+		fmt.Sprintf("os %s %+d", osToken, osScore),
+		fmt.Sprintf("arch %s %+d", archToken, archScore),
+	}
+	switch {
+	case isLinuxPackage(name):
+		score += scorePackage
+		reasons = append(reasons, fmt.Sprintf("package %+d", scorePackage))
+	case IsSupportedFormat(name):
+		score += scoreArchive
+		reasons = append(reasons, fmt.Sprintf("archive %+d", scoreArchive))
+	case strings.HasSuffix(name, ".exe") && cfg.OS != "windows":
+		return 0, []string{"windows executable"}
+	case isRawBinary(name):
+		score += scoreRaw
+		reasons = append(reasons, fmt.Sprintf("raw binary %+d", scoreRaw))
+	default:
+		return 0, []string{"unsupported format"}
+	}
+
+	if repoName != "" && strings.HasPrefix(name, strings.ToLower(repoName)) {
+		score += scoreRepo
+		reasons = append(reasons, fmt.Sprintf("repo name %+d", scoreRepo))
+	}
+	if libc, reason := libcScore(cfg.Libc, libcOf(tokens)); libc != 0 {
+		score += libc
+		reasons = append(reasons, reason)
+	}
+	for _, t := range tokens {
+		if debugTokens[t] {
+			score += scoreDebug
+			reasons = append(reasons, fmt.Sprintf("%s build %+d", t, scoreDebug))
+			break
+		}
+	}
+
+	// Keep debug builds installable when nothing else matches
+	return max(score, 1), reasons
+}
+
+// libcScore scores the libc an asset is built for against the host libc
+func libcScore(host, asset string) (int, string) {
+	var score int
+	switch {
+	case host == "" || asset == "":
+		return 0, ""
+	case asset == host:
+		score = scoreLibc
+	case asset == "static":
+		score = scoreStatic
+	case host == LibcGNU && asset == LibcMusl:
+		score = scoreMuslOnGNU
+	case host == LibcMusl && asset == LibcGNU:
+		score = scoreGNUOnMusl
+	}
+	return score, fmt.Sprintf("libc %s %+d", asset, score)
+}
+
+// isInstallable reports whether an asset could hold an executable grip can
+// install, regardless of its platform
+func isInstallable(name string) bool {
+	if !IsSupportedFormat(name) && !isRawBinary(name) {
+		return false
+	}
+	for _, t := range tokenize(name) {
+		if nonBinaryTokens[t] {
+			return false
+		}
+	}
+	return true
+}
+
+// rankAssets scores all assets for the platform of cfg, best first. Assets
+// with the same score keep their release order.
+func rankAssets(assets []*github.ReleaseAsset, cfg *Config, repoName string) []assetCandidate {
+	candidates := make([]assetCandidate, 0, len(assets))
+	for _, a := range assets {
+		name := strings.ToLower(a.GetName())
+		score, reasons := rankAsset(name, cfg, repoName)
+		candidates = append(candidates, assetCandidate{asset: a, name: name, score: score, reasons: reasons})
+	}
+
+	sort.SliceStable(candidates, func(i, j int) bool {
+		return candidates[i].score > candidates[j].score
+	})
+	return candidates
+}
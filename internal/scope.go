@@ -0,0 +1,106 @@
+package grip
+
+import (
+	"fmt"
+	"net/http"
+	"os"
+	"path/filepath"
+	"runtime"
+
+	"github.com/alexjoedt/grip/internal/logger"
+)
+
+// Scope selects where packages are installed and recorded
+type Scope string
+
+const (
+	ScopeUser   Scope = "user"   // the user's bin directory and registry
+	ScopeSystem Scope = "system" // SystemPrefix/bin and the shared registry
+)
+
+// ScopeOf returns the scope selected by a --system flag
+func ScopeOf(system bool) Scope {
+	if system {
+		return ScopeSystem
+	}
+	return ScopeUser
+}
+
+// Scopes provides the installer of each scope. Installers are created on
+// first use, so user operations never touch system paths and system
+// operations, often run with sudo, never create the user's directories.
+type Scopes struct {
+	config     *Config
+	ghClient   GitHubClient
+	httpClient *http.Client
+
+	user   *Installer
+	system *Installer
+}
+
+// NewScopes creates the scopes for the user config cfg
+func NewScopes(cfg *Config, ghClient GitHubClient, httpClient *http.Client) *Scopes {
+	return &Scopes{config: cfg, ghClient: ghClient, httpClient: httpClient}
+}
+
+// Installer returns the installer of the given scope
+func (s *Scopes) Installer(scope Scope) (*Installer, error) {
+	if scope != ScopeSystem {
+		return s.userInstaller()
+	}
+	if s.system != nil {
+		return s.system, nil
+	}
+
+	cfg := s.config.SystemScope()
+
+	// Without root the registry can only be read
+	storage := &Storage{filepath: cfg.StorePath}
+	if isRoot() {
+		if err := cfg.EnsureDirs(); err != nil {
+			return nil, fmt.Errorf("create system directories: %w", err)
+		}
+		var err error
+		if storage, err = NewStorage(cfg.StorePath, cfg); err != nil {
+			return nil, fmt.Errorf("initialize system storage: %w", err)
+		}
+	}
+
+	s.system = NewInstaller(cfg, storage, s.ghClient, s.httpClient)
+	return s.system, nil
+}
+
+// userInstaller returns the installer of the user scope, migrating data of
+// older versions and creating the user's directories first
+func (s *Scopes) userInstaller() (*Installer, error) {
+	if s.user != nil {
+		return s.user, nil
+	}
+	cfg := s.config
+
+	// Move data of older versions from ~/.grip into the current layout
+	migrated, err := MigrateLegacyHome(cfg)
+	if err != nil {
+		return nil, fmt.Errorf("migrate %s: %w", cfg.LegacyHomeDir, err)
+	}
+	if migrated {
+		logger.Warn("Moved grip data from %s, binaries are now installed to '%s'", cfg.LegacyHomeDir, cfg.BinDir)
+		logger.Warn("Replace '%s' with '%s' in your PATH", filepath.Join(cfg.LegacyHomeDir, "bin"), cfg.BinDir)
+	}
+
+	if err := cfg.EnsureDirs(); err != nil {
+		return nil, fmt.Errorf("create directories: %w", err)
+	}
+	storage, err := NewStorage(cfg.StorePath, cfg)
+	if err != nil {
+		return nil, fmt.Errorf("initialize storage: %w", err)
+	}
+
+	s.user = NewInstaller(cfg, storage, s.ghClient, s.httpClient)
+	return s.user, nil
+}
+
+// isRoot reports whether grip runs with root privileges
+func isRoot() bool {
+	return runtime.GOOS != "windows" && os.Geteuid() == 0
+}
@@ -0,0 +1,44 @@
+package grip
+
+import (
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// TestScopes tests that the directories of a scope are only created when
+// the scope is used
+func TestScopes(t *testing.T) {
+	t.Parallel()
+
+	home := filepath.Join(t.TempDir(), "home")
+	system := t.TempDir()
+	cfg := &Config{
+		HomeDir:         filepath.Join(home, ".local", "share", "grip"),
+		BinDir:          filepath.Join(home, ".local", "share", "grip", "bin"),
+		StorePath:       filepath.Join(home, ".local", "state", "grip", "grip.json"),
+		TempDir:         filepath.Join(home, ".cache", "grip"),
+		LegacyHomeDir:   filepath.Join(home, ".grip"),
+		SystemPrefix:    filepath.Join(system, "usr", "local"),
+		SystemStorePath: filepath.Join(system, "var", "lib", "grip", "grip.json"),
+	}
+	scopes := NewScopes(cfg, nil, nil)
+
+	installer, err := scopes.Installer(ScopeSystem)
+	require.NoError(t, err)
+	assert.Equal(t, filepath.Join(system, "usr", "local", "bin"), installer.config.BinDir)
+	assert.NoDirExists(t, home, "system scope created user directories")
+
+	installer, err = scopes.Installer(ScopeUser)
+	require.NoError(t, err)
+	assert.Equal(t, cfg.BinDir, installer.config.BinDir)
+	for _, dir := range []string{cfg.HomeDir, cfg.BinDir, filepath.Dir(cfg.StorePath), cfg.TempDir} {
+		assert.DirExists(t, dir)
+	}
+
+	again, err := scopes.Installer(ScopeUser)
+	require.NoError(t, err)
+	assert.Same(t, installer, again)
+}
@@ -0,0 +1,116 @@
+package grip
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"sync"
+
+	"github.com/alexjoedt/grip/internal/logger"
+	"github.com/google/go-github/v56/github"
+)
+
+// SearchResult is a repository found by Search
+type SearchResult struct {
+	Repo        string `json:"repo"`
+	Description string `json:"description,omitempty"`
+	Stars       int    `json:"stars"`
+	Tag         string `json:"tag,omitempty"`   // latest release, if any
+	Asset       string `json:"asset,omitempty"` // asset installed on the platform, if any
+	Error       string `json:"error,omitempty"` // why the latest release is unknown
+}
+
+// Installable reports whether the latest release has an asset for the
+// platform
+func (r SearchResult) Installable() bool {
+	return r.Asset != ""
+}
+
+// Search searches GitHub for repositories matching query, best match first,
+// and looks up which of them have a latest release with an asset that
+// install selects for the platform. A failed lookup leaves the release of
+// the repository unknown.
+func (i *Installer) Search(ctx context.Context, query string, limit int) ([]SearchResult, error) {
+	repos, err := i.ghClient.SearchRepositories(ctx, query, limit)
+	if err != nil {
+		return nil, fmt.Errorf("search repositories: %w", err)
+	}
+
+	results := make([]SearchResult, len(repos))
+	err = runConcurrently(ctx, i.config.Concurrency, len(repos), func(ctx context.Context, n int) error {
+		repo := repos[n]
+		owner, name := repo.GetOwner().GetLogin(), repo.GetName()
+		results[n] = SearchResult{
+			Repo:        "github.com/" + owner + "/" + name,
+			Description: repo.GetDescription(),
+			Stars:       repo.GetStargazersCount(),
+		}
+
+		release, err := i.ghClient.GetLatestRelease(ctx, owner, name)
+		if isNotFound(err) {
+			return nil
+		}
+		if err != nil {
+			logger.Info("Could not fetch the latest release of %s/%s: %v", owner, name, err)
+			results[n].Error = err.Error()
+			return nil
+		}
+		results[n].Tag = release.GetTagName()
+
+		inst, _ := i.storage.GetByRepo(results[n].Repo)
+		if asset, err := i.selectReleaseAsset(release, name, i.assetPattern(owner, name, inst)); err == nil {
+			results[n].Asset = asset
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return results, nil
+}
+
+// isNotFound reports whether a GitHub request failed as the resource, e.g.
+// the latest release, doesn't exist
+func isNotFound(err error) bool {
+	var rerr *github.ErrorResponse
+	if errors.As(err, &rerr) && rerr.Response != nil {
+		return rerr.Response.StatusCode == http.StatusNotFound
+	}
+	return errors.Is(err, ErrNotFound)
+}
+
+// runConcurrently calls fn for 0..count-1 with at most limit calls running
+// at the same time. The first error cancels the remaining calls.
+func runConcurrently(ctx context.Context, limit, count int, fn func(ctx context.Context, n int) error) error {
+	if limit < 1 {
+		limit = 1
+	}
+
+	ctx, cancel := context.WithCancelCause(ctx)
+	defer cancel(nil)
+
+	var wg sync.WaitGroup
+	sem := make(chan struct{}, limit)
+	for n := 0; n < count; n++ {
+		select {
+		case sem <- struct{}{}:
+		case <-ctx.Done():
+		}
+		if ctx.Err() != nil {
+			break
+		}
+
+		wg.Add(1)
+		go func(n int) {
+			defer wg.Done()
+			defer func() { <-sem }()
+			if err := fn(ctx, n); err != nil {
+				cancel(err)
+			}
+		}(n)
+	}
+	wg.Wait()
+
+	return context.Cause(ctx)
+}
@@ -0,0 +1,230 @@
+package grip
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"strings"
+
+	"github.com/alexjoedt/grip/internal/logger"
+)
+
+// Directories below the share directory, as used by man and the shells
+const (
+	shareMan  = "man"
+	shareBash = "bash-completion/completions"
+	shareZsh  = "zsh/site-functions"
+	shareFish = "fish/vendor_completions.d"
+)
+
+// manPageRe matches man pages like tool.1 or tool.1.gz, but no versions
+// like tool-1.2.1
+var manPageRe = regexp.MustCompile(`^[A-Za-z][\w+-]*\.([1-9])(\.gz)?$`)
+
+// shareFile is a man page or shell completion found in an unpacked archive
+type shareFile struct {
+	src  string // path in the unpack directory
+	dest string // path relative to the share directory
+}
+
+// findShareFiles returns the man pages and shell completions in the
+// directory tree, except the files in skip
+func findShareFiles(dir string, skip []string) ([]shareFile, error) {
+	var files []shareFile
+	err := filepath.WalkDir(dir, func(path string, d os.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if !d.Type().IsRegular() || slices.Contains(skip, path) {
+			return nil
+		}
+		rel, err := filepath.Rel(dir, path)
+		if err != nil {
+			return err
+		}
+		if dest := shareDest(filepath.ToSlash(rel)); dest != "" {
+			files = append(files, shareFile{src: path, dest: dest})
+		}
+		return nil
+	})
+	return files, err
+}
+
+// shareDest returns the destination of the archive entry rel below the
+// share directory, or "" if it's neither a man page nor a shell completion
+func shareDest(rel string) string {
+	base := filepath.Base(rel)
+	parent := strings.ToLower(filepath.Base(filepath.Dir(rel)))
+	ext := strings.ToLower(filepath.Ext(base))
+
+	if m := manPageRe.FindStringSubmatch(base); m != nil {
+		return filepath.Join(shareMan, "man"+m[1], base)
+	}
+
+	switch {
+	case ext == ".bash":
+		return filepath.Join(shareBash, strings.TrimSuffix(base, filepath.Ext(base)))
+	case strings.HasSuffix(strings.ToLower(base), ".bash-completion"):
+		return filepath.Join(shareBash, base[:len(base)-len(".bash-completion")])
+	case parent == "bash" && ext == "":
+		return filepath.Join(shareBash, base)
+	case ext == ".zsh":
+		name := strings.TrimSuffix(base, filepath.Ext(base))
+		if !strings.HasPrefix(name, "_") {
+			name = "_" + name
+		}
+		return filepath.Join(shareZsh, name)
+	case strings.HasPrefix(base, "_") && len(base) > 1 && ext == "":
+		return filepath.Join(shareZsh, base)
+	case parent == "zsh" && ext == "":
+		return filepath.Join(shareZsh, "_"+base)
+	case ext == ".fish":
+		return filepath.Join(shareFish, base)
+	}
+	return ""
+}
+
+// installShareFiles copies the man pages and shell completions of the
+// installation name into the share directory and returns their paths. Files
+// owned by another installation, or existing without being installed by
+// grip, are skipped. The shell setup is printed once, when a directory is
+// created.
+func (i *Installer) installShareFiles(name string, files []shareFile) ([]string, error) {
+	shareDir := i.config.ShareDir
+	if shareDir == "" || len(files) == 0 {
+		return nil, nil
+	}
+	owners, err := i.shareOwners(name)
+	if err != nil {
+		return nil, err
+	}
+	var own []string
+	if inst, err := i.storage.Get(name); err == nil {
+		own = inst.ShareFiles
+	}
+
+	var created []string
+	var paths []string
+	for _, f := range files {
+		dest := filepath.Join(shareDir, f.dest)
+		if owner, ok := owners[dest]; ok {
+			logger.Warn("Skipping %s, it's installed by %s", dest, owner)
+			continue
+		}
+		if _, err := os.Lstat(dest); err == nil && !slices.Contains(own, dest) {
+			logger.Warn("Skipping %s, it exists and wasn't installed by grip", dest)
+			continue
+		}
+		kind := shareKind(f.dest)
+		if _, err := os.Stat(filepath.Join(shareDir, kind)); os.IsNotExist(err) && !slices.Contains(created, kind) {
+			created = append(created, kind)
+		}
+		if err := copyShareFile(f.src, dest); err != nil {
+			return paths, fmt.Errorf("install %s: %w", filepath.Base(dest), err)
+		}
+		logger.Info("Installed %s", dest)
+		paths = append(paths, dest)
+	}
+
+	for _, kind := range created {
+		if hint := shellSetup(shareDir, kind); hint != "" {
+			logger.Println("%s", hint)
+		}
+	}
+	return paths, nil
+}
+
+// shareKind returns the directory of a share destination, e.g. "man"
+func shareKind(dest string) string {
+	dest = filepath.ToSlash(dest)
+	for _, kind := range []string{shareBash, shareZsh, shareFish} {
+		if strings.HasPrefix(dest, kind+"/") {
+			return kind
+		}
+	}
+	return shareMan
+}
+
+// shellSetup returns the one-time setup making man or a shell find the
+// files in the kind directory below shareDir
+func shellSetup(shareDir, kind string) string {
+	dir := filepath.Join(shareDir, kind)
+	switch kind {
+	case shareMan:
+		return fmt.Sprintf("Man pages are installed to %s, add to your shell profile:\n  export MANPATH=\"%s:$MANPATH\"", dir, dir)
+	case shareBash:
+		return fmt.Sprintf("Bash completions are installed to %s, add to ~/.bashrc:\n  for f in %s/*; do . \"$f\"; done", dir, dir)
+	case shareZsh:
+		return fmt.Sprintf("Zsh completions are installed to %s, add to ~/.zshrc before compinit:\n  fpath=(%s $fpath)", dir, dir)
+	case shareFish:
+		return fmt.Sprintf("Fish completions are installed to %s, run once:\n  set -U fish_complete_path %s $fish_complete_path", dir, dir)
+	}
+	return ""
+}
+
+// copyShareFile copies src to dest, readable for everyone
+func copyShareFile(src, dest string) error {
+	if err := os.MkdirAll(filepath.Dir(dest), 0755); err != nil {
+		return err
+	}
+	in, err := os.Open(src)
+	if err != nil {
+		return err
+	}
+	defer in.Close()
+
+	out, err := os.OpenFile(dest, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, 0644)
+	if err != nil {
+		return err
+	}
+	if _, err := io.Copy(out, in); err != nil {
+		out.Close()
+		return err
+	}
+	return out.Close()
+}
+
+// shareOwners maps the man pages and shell completions of the recorded
+// installations other than name to the installation owning them
+func (i *Installer) shareOwners(name string) (map[string]string, error) {
+	insts, err := i.storage.List()
+	if err != nil {
+		return nil, fmt.Errorf("read installations: %w", err)
+	}
+	owners := make(map[string]string)
+	for _, inst := range insts {
+		if inst.Name == name {
+			continue
+		}
+		for _, p := range inst.ShareFiles {
+			owners[p] = inst.Name
+		}
+	}
+	return owners, nil
+}
+
+// removeOwnShareFiles deletes the man pages and shell completions in paths
+// of the installation name, except those another installation owns
+func (i *Installer) removeOwnShareFiles(name string, paths []string) {
+	owners, err := i.shareOwners(name)
+	if err != nil {
+		logger.Warn("Keeping man pages and completions of %s: %v", name, err)
+		return
+	}
+	removeShareFiles(slices.DeleteFunc(slices.Clone(paths), func(p string) bool {
+		_, owned := owners[p]
+		return owned
+	}))
+}
+
+// removeShareFiles deletes installed man pages and shell completions
+func removeShareFiles(paths []string) {
+	for _, p := range paths {
+		if err := os.Remove(p); err != nil && !os.IsNotExist(err) {
+			logger.Warn("Could not remove %s: %v", p, err)
+		}
+	}
+}
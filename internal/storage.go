@@ -22,6 +22,29 @@ type Installation struct {
 	InstalledAt time.Time `json:"installedAt"`
 	UpdatedAt   time.Time `json:"updatedAt"`
 	InstallPath string    `json:"installPath"`
+
+	// Asset selection overrides, reused by update
+	AssetPattern  string   `json:"assetPattern,omitempty"`
+	Binary        string   `json:"binary,omitempty"`
+	SkipArchCheck bool     `json:"skipArchCheck,omitempty"`
+	Bins          []string `json:"bins,omitempty"`
+	AllBins       bool     `json:"allBins,omitempty"`
+	Scripts       bool     `json:"scripts,omitempty"`
+
+	// Installed files in InstallPath, including Name
+	Files []string `json:"files,omitempty"`
+
+	// Installed man pages and shell completions
+	ShareFiles []string `json:"shareFiles,omitempty"`
+}
+
+// InstalledFiles returns the names of the installed files in InstallPath.
+// Installations of older versions only record Name.
+func (inst *Installation) InstalledFiles() []string {
+	if len(inst.Files) == 0 {
+		return []string{inst.Name}
+	}
+	return inst.Files
 }
 
 // repoEntry is used for migrating from the old lock file format
@@ -138,6 +161,10 @@ func (s *Storage) Delete(name string) error {
 // load reads storage from disk
 func (s *Storage) load() (map[string]*Installation, error) {
 	f, err := os.Open(s.filepath)
+	if os.IsNotExist(err) {
+		// Nothing installed yet, e.g. a system registry read without root
+		return make(map[string]*Installation), nil
+	}
 	if err != nil {
 		return nil, err
 	}
@@ -155,11 +182,17 @@ func (s *Storage) load() (map[string]*Installation, error) {
 func (s *Storage) save(data map[string]*Installation) error {
 	tmpPath := s.filepath + ".tmp"
 
-	f, err := os.Create(tmpPath)
+	f, err := os.OpenFile(tmpPath, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, 0644)
 	if err != nil {
 		return err
 	}
 
+	// The registry must stay readable for everyone, regardless of the umask
+	if err := f.Chmod(0644); err != nil {
+		f.Close()
+		return err
+	}
+
 	enc := json.NewEncoder(f)
 	enc.SetIndent("", "  ")
 	if err := enc.Encode(data); err != nil {
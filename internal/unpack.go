@@ -3,101 +3,220 @@ package grip
 import (
 	"archive/tar"
 	"archive/zip"
+	"bytes"
 	"compress/bzip2"
 	"compress/gzip"
 	"errors"
 	"fmt"
 	"io"
 	"os"
+	"path"
 	"path/filepath"
 	"strings"
 
+	"github.com/alexjoedt/grip/internal/logger"
+	"github.com/bodgit/sevenzip"
 	"github.com/h2non/filetype"
-	"github.com/schollz/progressbar/v3"
+	"github.com/klauspost/compress/zstd"
 	"github.com/ulikunitz/xz"
 )
 
-type unpackFn func(io.Reader, string, *progressbar.ProgressBar) error
+// unpackFn extracts the archive src with x. src reads the archive from its
+// start and allows random access for formats with a central directory.
+type unpackFn func(src *io.SectionReader, x *extractor) error
 
 // sanitizePath joins destination and name, then verifies the result stays
 // inside destination, preventing zip-slip / path traversal attacks.
 // Absolute paths in archive entries are rejected explicitly.
 func sanitizePath(destination, name string) (string, error) {
 	if filepath.IsAbs(name) {
-		return "", fmt.Errorf("path traversal attempt: %q escapes destination directory", name)
+		return "", fmt.Errorf("%w: %q escapes destination directory", ErrUnsafePath, name)
 	}
 	target := filepath.Clean(filepath.Join(destination, name))
-	rel, err := filepath.Rel(destination, target)
-	if err != nil || rel == ".." || strings.HasPrefix(rel, ".."+string(filepath.Separator)) || filepath.IsAbs(rel) {
-		return "", fmt.Errorf("path traversal attempt: %q escapes destination directory", name)
+	if !isWithin(destination, target) {
+		return "", fmt.Errorf("%w: %q escapes destination directory", ErrUnsafePath, name)
 	}
 	return target, nil
 }
 
 var unpackers = map[string]unpackFn{
+	".tar":     unpackPlainTar,
 	".tar.gz":  unpackTarGz,
 	".tgz":     unpackTarGz,
 	".tar.bz2": unpackTarBz2,
 	".tbz":     unpackTarBz2,
 	".zip":     unpackZip,
 	".tar.xz":  unpackTarXz,
+	".txz":     unpackTarXz,
+	".tar.zst": unpackTarZst,
+	".tzst":    unpackTarZst,
+	".7z":      unpack7z,
+	".deb":     unpackDeb,
+	".rpm":     unpackRpm,
 	".bz2":     unpackBz2,
+	".gz":      unpackGz,
+	".xz":      unpackXz,
+	".zst":     unpackZst,
 }
 
 // orderedExts lists supported archive extensions sorted by descending length
 // so that longer suffixes (e.g. .tar.bz2) are matched before shorter ones (e.g. .bz2).
 var orderedExts = []string{
 	".tar.bz2",
+	".tar.zst",
 	".tar.gz",
-	".tgz",
 	".tar.xz",
+	".tzst",
+	".tgz",
 	".tbz",
+	".txz",
+	".tar",
 	".zip",
 	".bz2",
+	".zst",
+	".deb",
+	".rpm",
+	".gz",
+	".xz",
+	".7z",
+}
+
+// compressedFileExts are formats compressing a single file instead of an archive
+var compressedFileExts = map[string]bool{".bz2": true, ".gz": true, ".xz": true, ".zst": true}
+
+// archiveMagics are the leading bytes of the supported formats
+var archiveMagics = []struct {
+	magic []byte
+	ext   string
+}{
+	{[]byte{0x1f, 0x8b}, ".gz"},
+	{[]byte("BZh"), ".bz2"},
+	{[]byte{0xfd, '7', 'z', 'X', 'Z', 0x00}, ".xz"},
+	{[]byte{0x28, 0xb5, 0x2f, 0xfd}, ".zst"},
+	{[]byte("PK\x03\x04"), ".zip"},
+	{[]byte{'7', 'z', 0xbc, 0xaf, 0x27, 0x1c}, ".7z"},
+	{[]byte("!<arch>\n"), ".deb"},
+	{[]byte{0xed, 0xab, 0xee, 0xdb}, ".rpm"},
+}
+
+// decompressors open the compressed stream of the single file formats
+var decompressors = map[string]func(io.Reader) (io.ReadCloser, error){
+	".gz": func(r io.Reader) (io.ReadCloser, error) {
+		return gzip.NewReader(r)
+	},
+	".bz2": func(r io.Reader) (io.ReadCloser, error) {
+		return io.NopCloser(bzip2.NewReader(r)), nil
+	},
+	".xz": func(r io.Reader) (io.ReadCloser, error) {
+		xzr, err := xz.NewReader(r)
+		return io.NopCloser(xzr), err
+	},
+	".zst": func(r io.Reader) (io.ReadCloser, error) {
+		zr, err := zstd.NewReader(r)
+		if err != nil {
+			return nil, err
+		}
+		return zr.IOReadCloser(), nil
+	},
 }
 
 // Unpack extracts an archive file to the destination directory.
 // Returns the path to the executable binary found in the archive.
 func Unpack(archivePath, destDir string) (string, error) {
+	return unpackExecutable(archivePath, destDir, DefaultUnpackLimits(), archiveSteps, nil, false)
+}
+
+// unpackExecutable is Unpack within limits, choosing the executable by the
+// names of the repository and including scripts if requested, see
+// findExecutable. The progress bar is labelled by steps.
+func unpackExecutable(archivePath, destDir string, limits UnpackLimits, steps, names []string, scripts bool) (string, error) {
+	if err := extract(archivePath, destDir, limits, steps); err != nil {
+		return "", err
+	}
+
+	execPath, err := findExecutable(destDir, names, scripts)
+	if err != nil {
+		return "", fmt.Errorf("find executable: %w", err)
+	}
+
+	return execPath, nil
+}
+
+// UnpackBinary extracts an archive file to the destination directory and
+// returns the path of the file named binary, which may include directories.
+func UnpackBinary(archivePath, destDir, binary string) (string, error) {
+	return unpackBinary(archivePath, destDir, binary, false, DefaultUnpackLimits(), archiveSteps)
+}
+
+// unpackBinary is UnpackBinary within limits, also matching binary with the
+// .exe suffix for windows. The progress bar is labelled by steps.
+func unpackBinary(archivePath, destDir, binary string, windows bool, limits UnpackLimits, steps []string) (string, error) {
+	if err := extract(archivePath, destDir, limits, steps); err != nil {
+		return "", err
+	}
+
+	binPath, err := findBinary(destDir, binary, windows)
+	if err != nil {
+		return "", fmt.Errorf("find executable: %w", err)
+	}
+
+	return binPath, nil
+}
+
+// extract unpacks an archive file into destDir within limits, labelling
+// the progress bar with the unpack step of steps
+func extract(archivePath, destDir string, limits UnpackLimits, steps []string) error {
 	archiveInfo, err := os.Stat(archivePath)
 	if err != nil {
-		return "", fmt.Errorf("stat archive: %w", err)
+		return fmt.Errorf("stat archive: %w", err)
 	}
 
-	ext, fn, err := getUnpackFn(archivePath)
+	ext, err := detectArchiveExt(archivePath)
 	if err != nil {
-		return "", err
+		return fmt.Errorf("detect archive format: %w", err)
 	}
+	if ext == "" {
+		if ext, _, err = getUnpackFn(archivePath); err != nil {
+			return err
+		}
+	} else if !strings.HasSuffix(strings.ToLower(archivePath), ext) {
+		logger.Info("Detected %s archive by content", ext)
+	}
+	fn := unpackers[ext]
 
 	if err := os.MkdirAll(destDir, 0755); err != nil {
-		return "", fmt.Errorf("create destination directory: %w", err)
+		return fmt.Errorf("create destination directory: %w", err)
 	}
 
 	archive, err := os.Open(archivePath)
 	if err != nil {
-		return "", fmt.Errorf("open archive: %w", err)
+		return fmt.Errorf("open archive: %w", err)
 	}
 	defer archive.Close()
 
-	unpackDest := destDir
-	if ext == ".bz2" {
-		// .bz2 files need special handling
-		unpackDest = filepath.Join(destDir, filepath.Base(archivePath))
-		unpackDest = strings.TrimSuffix(unpackDest, ext)
+	// Progress is reported per extracted byte, the total is only known
+	// for zip and 7z archives
+	bar := NewProgressBar(-1, stepLabel(steps, stepUnpack))
+	x := newExtractor(destDir)
+	x.limits = limits
+	x.archiveSize = archiveInfo.Size()
+	x.bar = bar
+	if compressedFileExts[ext] {
+		// A single compressed file is unpacked to its name without extension
+		name := filepath.Base(archivePath)
+		if strings.HasSuffix(strings.ToLower(name), ext) {
+			name = name[:len(name)-len(ext)]
+		}
+		x.single = name
 	}
 
-	bar := NewProgressBar(int(archiveInfo.Size()), "[cyan][2/3][reset] Unpacking")
-	if err := fn(archive, unpackDest, bar); err != nil {
-		return "", fmt.Errorf("unpack archive: %w", err)
+	if err := fn(io.NewSectionReader(archive, 0, archiveInfo.Size()), x); err != nil {
+		return fmt.Errorf("unpack archive: %w", err)
 	}
+	_ = bar.Finish()
 	fmt.Println() // new line after progress bar
 
-	execPath, err := findExecutable(destDir)
-	if err != nil {
-		return "", fmt.Errorf("find executable: %w", err)
-	}
-
-	return execPath, nil
+	return nil
 }
 
 // IsSupportedFormat reports whether filename has a supported archive extension.
@@ -111,6 +230,79 @@ func IsSupportedFormat(filename string) bool {
 	return false
 }
 
+// isRawBinary reports whether an asset is an executable published without
+// archive, which is assumed for names without file extension, e.g.
+// tool-linux-amd64, Windows executables and self-contained AppImages. The dots of versions like
+// tool-1.2-linux-amd64 don't start an extension.
+func isRawBinary(filename string) bool {
+	ext := strings.ToLower(path.Ext(filename))
+	if ext == "" || ext == ".appimage" || ext == ".exe" {
+		return true
+	}
+	ext = ext[1:]
+	return strings.ContainsAny(ext, "-_ ") || strings.Trim(ext, "0123456789") == ""
+}
+
+// checkExecutable checks by content that the downloaded raw binary at path
+// is an executable binary or script
+func checkExecutable(path string) error {
+	if isExecutableBinary(path) || isScript(path) {
+		return nil
+	}
+	return fmt.Errorf("%w: %s is not an executable", ErrInvalidAsset, filepath.Base(path))
+}
+
+// detectArchiveExt detects the format of an archive by its leading bytes and
+// returns its extension, or "" if it isn't one. Compressed tar archives are
+// told apart from compressed files by the tar header.
+func detectArchiveExt(path string) (string, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return "", err
+	}
+	defer f.Close()
+
+	header := make([]byte, 512)
+	n, err := io.ReadFull(f, header)
+	if err != nil && !errors.Is(err, io.ErrUnexpectedEOF) && !errors.Is(err, io.EOF) {
+		return "", err
+	}
+	header = header[:n]
+	if isTarHeader(header) {
+		return ".tar", nil
+	}
+
+	for _, m := range archiveMagics {
+		if !bytes.HasPrefix(header, m.magic) {
+			continue
+		}
+		open, ok := decompressors[m.ext]
+		if !ok {
+			return m.ext, nil
+		}
+		if _, err := f.Seek(0, io.SeekStart); err != nil {
+			return "", err
+		}
+		r, err := open(f)
+		if err != nil {
+			return "", err
+		}
+		defer r.Close()
+		inner := make([]byte, 512)
+		n, _ := io.ReadFull(r, inner)
+		if isTarHeader(inner[:n]) {
+			return ".tar" + m.ext, nil
+		}
+		return m.ext, nil
+	}
+	return "", nil
+}
+
+// isTarHeader reports whether header starts with a POSIX or GNU tar header
+func isTarHeader(header []byte) bool {
+	return len(header) >= 262 && string(header[257:262]) == "ustar"
+}
+
 // getUnpackFn returns the appropriate unpacker function for the filename.
 func getUnpackFn(filename string) (string, unpackFn, error) {
 	filename = strings.ToLower(filename)
@@ -119,47 +311,49 @@ func getUnpackFn(filename string) (string, unpackFn, error) {
 			return ext, unpackers[ext], nil
 		}
 	}
-	return "", nil, fmt.Errorf("unsupported archive format: %s", filename)
+	return "", nil, fmt.Errorf("%w: %s", ErrUnsupportedFormat, filename)
 }
 
-// findExecutable searches for an executable binary in the directory tree.
-func findExecutable(dir string) (string, error) {
-	fileTypes := map[string]bool{
-		"application/x-mach-binary": true,
-		"application/x-executable":  true,
+// findBinary searches the directory tree for the file named binary. A name
+// with slashes matches the end of the path, e.g. "bin/tool". For windows the
+// name also matches with the .exe suffix, e.g. "rg.exe" for "rg".
+func findBinary(dir, binary string, windows bool) (string, error) {
+	binary = strings.Trim(filepath.ToSlash(binary), "/")
+	names := []string{binary}
+	if windows && withExeSuffix(binary) != binary {
+		names = append(names, withExeSuffix(binary))
 	}
 
-	var executablePath string
-	err := filepath.Walk(dir, func(path string, info os.FileInfo, err error) error {
+	var binPath string
+	err := filepath.WalkDir(dir, func(path string, d os.DirEntry, err error) error {
 		if err != nil {
 			return err
 		}
+		if d.IsDir() {
+			return nil
+		}
 
-		if !info.IsDir() {
-			mimeType, err := detectFileType(path)
-			if err != nil {
-				// Continue searching on error
-				return nil
-			}
-
-			if fileTypes[mimeType] {
-				executablePath = path
-				return filepath.SkipAll // Stop walking
+		rel, err := filepath.Rel(dir, path)
+		if err != nil {
+			return err
+		}
+		rel = filepath.ToSlash(rel)
+		for _, name := range names {
+			if rel == name || strings.HasSuffix(rel, "/"+name) {
+				binPath = path
+				return filepath.SkipAll
 			}
 		}
-
 		return nil
 	})
-
 	if err != nil {
 		return "", err
 	}
 
-	if executablePath == "" {
-		return "", errors.New("no executable found in archive")
+	if binPath == "" {
+		return "", fmt.Errorf("%w: %s in archive", ErrNotFound, binary)
 	}
-
-	return executablePath, nil
+	return binPath, nil
 }
 
 // detectFileType detects the MIME type of a file.
@@ -184,9 +378,9 @@ func detectFileType(path string) (string, error) {
 	return kind.MIME.Value, nil
 }
 
-// unpackTar iterates over a tar stream and extracts entries into destination.
+// unpackTar iterates over a tar stream and extracts entries with x.
 // r should already be a decompressed reader (the caller handles decompression).
-func unpackTar(r io.Reader, destination string) error {
+func unpackTar(r io.Reader, x *extractor) error {
 	tr := tar.NewReader(r)
 	for {
 		header, err := tr.Next()
@@ -197,127 +391,167 @@ func unpackTar(r io.Reader, destination string) error {
 			return err
 		}
 
-		target, err := sanitizePath(destination, header.Name)
-		if err != nil {
-			return err
-		}
-
 		switch header.Typeflag {
 		case tar.TypeDir:
-			if err := os.MkdirAll(target, os.FileMode(header.Mode).Perm()); err != nil {
-				return err
-			}
+			err = x.mkdir(header.Name)
 		case tar.TypeReg:
-			if err := os.MkdirAll(filepath.Dir(target), 0755); err != nil {
-				return err
-			}
-			f, err := os.OpenFile(target, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, os.FileMode(header.Mode).Perm())
-			if err != nil {
-				return err
-			}
-			if _, err := io.Copy(f, tr); err != nil {
-				if cerr := f.Close(); cerr != nil {
-					return errors.Join(err, cerr)
-				}
-				return err
-			}
-			if err := f.Close(); err != nil {
-				return err
-			}
+			err = x.writeFile(header.Name, tr, os.FileMode(header.Mode))
+		case tar.TypeSymlink:
+			err = x.symlink(header.Name, header.Linkname)
+		case tar.TypeLink:
+			err = x.hardlink(header.Name, header.Linkname)
+		}
+		if err != nil {
+			return err
 		}
 	}
 	return nil
 }
 
-func unpackTarGz(packageFile io.Reader, destination string, bar *progressbar.ProgressBar) error {
-	gzr, err := gzip.NewReader(io.TeeReader(packageFile, bar))
+func unpackTarGz(src *io.SectionReader, x *extractor) error {
+	gzr, err := gzip.NewReader(src)
 	if err != nil {
 		return err
 	}
 	defer gzr.Close()
-	return unpackTar(gzr, destination)
+	return unpackTar(gzr, x)
+}
+
+func unpackPlainTar(src *io.SectionReader, x *extractor) error {
+	return unpackTar(src, x)
+}
+
+func unpackTarBz2(src *io.SectionReader, x *extractor) error {
+	return unpackTar(bzip2.NewReader(src), x)
+}
+
+func unpackBz2(src *io.SectionReader, x *extractor) error {
+	return unpackFile(src, x, ".bz2")
+}
+
+func unpackGz(src *io.SectionReader, x *extractor) error {
+	return unpackFile(src, x, ".gz")
 }
 
-func unpackTarBz2(packageFile io.Reader, destination string, bar *progressbar.ProgressBar) error {
-	bzr := bzip2.NewReader(io.TeeReader(packageFile, bar))
-	return unpackTar(bzr, destination)
+func unpackXz(src *io.SectionReader, x *extractor) error {
+	return unpackFile(src, x, ".xz")
 }
 
-func unpackBz2(packageReader io.Reader, destination string, bar *progressbar.ProgressBar) error {
-	bz2Reader := bzip2.NewReader(io.TeeReader(packageReader, bar))
+func unpackZst(src *io.SectionReader, x *extractor) error {
+	return unpackFile(src, x, ".zst")
+}
 
-	outFile, err := os.Create(destination)
+// unpackFile decompresses a single compressed file to x.single
+func unpackFile(src *io.SectionReader, x *extractor, ext string) error {
+	r, err := decompressors[ext](src)
 	if err != nil {
 		return err
 	}
-	defer outFile.Close()
+	defer r.Close()
 
-	_, err = io.Copy(outFile, bz2Reader)
-	return err
+	return x.writeFile(x.single, r, 0644)
 }
 
-func unpackZip(packageFile io.Reader, destination string, bar *progressbar.ProgressBar) error {
+func unpackTarZst(src *io.SectionReader, x *extractor) error {
+	zr, err := zstd.NewReader(src)
+	if err != nil {
+		return err
+	}
+	defer zr.Close()
+	return unpackTar(zr, x)
+}
 
-	tmpFile, err := os.CreateTemp("", "temp-zip")
+// unpack7z reads the 7z archive in place, its index is at the end
+func unpack7z(src *io.SectionReader, x *extractor) error {
+	r, err := sevenzip.NewReader(src, src.Size())
 	if err != nil {
 		return err
 	}
-	defer os.Remove(tmpFile.Name())
 
-	if _, err = io.Copy(tmpFile, packageFile); err != nil {
+	var total int64
+	for _, f := range r.File {
+		total += int64(f.UncompressedSize)
+	}
+	x.expect(total)
+
+	for _, f := range r.File {
+		if err := unpack7zFile(f, x); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// unpack7zFile extracts a single 7z entry with x
+func unpack7zFile(f *sevenzip.File, x *extractor) error {
+	if f.FileInfo().IsDir() {
+		return x.mkdir(f.Name)
+	}
+
+	rc, err := f.Open()
+	if err != nil {
 		return err
 	}
+	defer rc.Close()
 
-	r, err := zip.OpenReader(tmpFile.Name())
+	if f.Mode()&os.ModeSymlink != 0 {
+		target, err := readLinkTarget(f.Name, rc)
+		if err != nil {
+			return err
+		}
+		return x.symlink(f.Name, target)
+	}
+	return x.writeFile(f.Name, rc, f.Mode())
+}
+
+// unpackZip reads the zip archive in place, its central directory is at
+// the end
+func unpackZip(src *io.SectionReader, x *extractor) error {
+	r, err := zip.NewReader(src, src.Size())
 	if err != nil {
 		return err
 	}
-	defer r.Close()
 
+	var total int64
 	for _, f := range r.File {
-		if err := func(f *zip.File) error {
-			rc, err := f.Open()
-			if err != nil {
-				return err
-			}
-			defer rc.Close()
+		total += int64(f.UncompressedSize64)
+	}
+	x.expect(total)
 
-			fpath, err := sanitizePath(destination, f.Name)
-			if err != nil {
-				return err
-			}
-			if f.FileInfo().IsDir() {
-				os.MkdirAll(fpath, os.ModePerm)
-			} else {
-				var fdir string
-				if lastIndex := strings.LastIndex(fpath, string(os.PathSeparator)); lastIndex > -1 {
-					fdir = fpath[:lastIndex]
-					os.MkdirAll(fdir, os.ModePerm)
-				}
-
-				outFile, err := os.OpenFile(
-					fpath, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, f.Mode())
-				if err != nil {
-					return err
-				}
-				defer outFile.Close()
-				_, err = io.Copy(outFile, rc)
-				if err != nil {
-					return err
-				}
-			}
-			return nil
-		}(f); err != nil {
+	for _, f := range r.File {
+		if err := unpackZipFile(f, x); err != nil {
 			return err
 		}
 	}
 	return nil
 }
 
-func unpackTarXz(packageFile io.Reader, destination string, bar *progressbar.ProgressBar) error {
-	xzr, err := xz.NewReader(io.TeeReader(packageFile, bar))
+// unpackZipFile extracts a single zip entry with x
+func unpackZipFile(f *zip.File, x *extractor) error {
+	if f.FileInfo().IsDir() {
+		return x.mkdir(f.Name)
+	}
+
+	rc, err := f.Open()
+	if err != nil {
+		return err
+	}
+	defer rc.Close()
+
+	if f.Mode()&os.ModeSymlink != 0 {
+		target, err := readLinkTarget(f.Name, rc)
+		if err != nil {
+			return err
+		}
+		return x.symlink(f.Name, target)
+	}
+	return x.writeFile(f.Name, rc, f.Mode())
+}
+
+func unpackTarXz(src *io.SectionReader, x *extractor) error {
+	xzr, err := xz.NewReader(src)
 	if err != nil {
 		return err
 	}
-	return unpackTar(xzr, destination)
+	return unpackTar(xzr, x)
 }
@@ -0,0 +1,123 @@
+package grip
+
+import (
+	"debug/elf"
+	"errors"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// fuzzUnpack runs fn on data and checks that nothing is written outside
+// the destination and that failures are errors, not panics
+func fuzzUnpack(t *testing.T, fn unpackFn, data []byte) {
+	root := t.TempDir()
+	dest := filepath.Join(root, "dest")
+	if err := os.Mkdir(dest, 0o755); err != nil {
+		t.Fatal(err)
+	}
+
+	x := newExtractor(dest)
+	x.limits = UnpackLimits{MaxSize: 1 << 20, MaxEntries: 100, MaxRatio: 100}
+	x.archiveSize = int64(len(data))
+	x.single = "file"
+	err := fn(newSource(data), x)
+
+	entries, rerr := os.ReadDir(root)
+	if rerr != nil {
+		t.Fatal(rerr)
+	}
+	if len(entries) != 1 {
+		t.Fatalf("unpacking wrote outside the destination: %v", entries)
+	}
+
+	if err == nil {
+		return
+	}
+	var uerr *UnpackError
+	if errors.As(err, &uerr) && !errors.Is(err, ErrUnsafePath) && !errors.Is(err, ErrUnpackLimit) && !errors.Is(err, ErrInvalidAsset) {
+		t.Fatalf("untyped unpack error: %v", err)
+	}
+}
+
+func FuzzUnpackTar(f *testing.F) {
+	exe := elfBinary(elf.ELFCLASS64, elf.ELFDATA2LSB, elf.EM_X86_64, elf.ELFOSABI_NONE)
+	seed := func(entries ...tarEntry) []byte {
+		r := newTarStream(f, entries)
+		return gzipBytes(f, r)
+	}
+	f.Add(seed(tarEntry{name: "bin/tool", content: exe, mode: 0o755}))
+	f.Add(seed(tarEntry{name: "tool", content: exe}, tarEntry{name: "link", symlink: "tool"}, tarEntry{name: "hard", link: "tool"}))
+	f.Add(seed(tarEntry{name: "evil", symlink: "../outside"}))
+	f.Add(seed(tarEntry{name: "a", symlink: "."}, tarEntry{name: "a/b", symlink: ".."}))
+
+	f.Fuzz(func(t *testing.T, data []byte) {
+		fuzzUnpack(t, unpackTarGz, data)
+	})
+}
+
+func FuzzUnpackZip(f *testing.F) {
+	f.Add(createTestZipWithExec(f))
+	f.Add(createMaliciousZip(f, "../evil.txt"))
+
+	f.Fuzz(func(t *testing.T, data []byte) {
+		fuzzUnpack(t, unpackZip, data)
+	})
+}
+
+func FuzzUnpack7z(f *testing.F) {
+	f.Add(create7z(f, "bin/tool", []byte("tool"), 0o755))
+	f.Add(create7z(f, "../evil", []byte("x"), 0o644))
+
+	f.Fuzz(func(t *testing.T, data []byte) {
+		fuzzUnpack(t, unpack7z, data)
+	})
+}
+
+func FuzzUnpackDeb(f *testing.F) {
+	f.Add(createDeb(f, ".gz", []tarEntry{{name: "./usr/bin/tool", content: []byte("tool"), mode: 0o755}}))
+	f.Add(createDeb(f, "", []tarEntry{{name: "../evil", content: []byte("x")}}))
+
+	f.Fuzz(func(t *testing.T, data []byte) {
+		fuzzUnpack(t, unpackDeb, data)
+	})
+}
+
+func FuzzUnpackRpm(f *testing.F) {
+	f.Add(createRpm(f, "", []cpioEntry{
+		{name: "./usr/bin/tool", content: []byte("tool"), mode: 0o100755},
+		{name: "./usr/bin/link", content: []byte("tool"), mode: 0o120777},
+	}))
+	f.Add(createRpm(f, ".gz", []cpioEntry{{name: "../evil", content: []byte("x"), mode: 0o100644}}))
+
+	f.Fuzz(func(t *testing.T, data []byte) {
+		fuzzUnpack(t, unpackRpm, data)
+	})
+}
+
+func FuzzUnpackCpio(f *testing.F) {
+	header := func(nameSize string) []byte {
+		return []byte("070701" + strings.Repeat("00000000", 11) + nameSize + "00000000" + "tool\x00\x00")
+	}
+	f.Add(header("-0000001"))
+	f.Add(header("00000000"))
+	f.Add(header("+0000005"))
+
+	f.Fuzz(func(t *testing.T, data []byte) {
+		fuzzUnpack(t, func(src *io.SectionReader, x *extractor) error {
+			return unpackCpio(src, x)
+		}, data)
+	})
+}
+
+func FuzzUnpackFile(f *testing.F) {
+	f.Add(compress(f, ".gz", []byte("tool")))
+	f.Add(compress(f, ".zst", make([]byte, 4<<20)))
+
+	f.Fuzz(func(t *testing.T, data []byte) {
+		fuzzUnpack(t, unpackGz, data)
+		fuzzUnpack(t, unpackZst, data)
+	})
+}
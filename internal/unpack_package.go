@@ -0,0 +1,211 @@
+package grip
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+)
+
+// linuxPackageExts are Linux package formats, used when a release has
+// nothing else for the platform. AppImages are installed as they are.
+var linuxPackageExts = []string{".deb", ".rpm", ".appimage"}
+
+// isLinuxPackage reports whether filename is a deb, rpm or AppImage
+func isLinuxPackage(filename string) bool {
+	filename = strings.ToLower(filename)
+	for _, ext := range linuxPackageExts {
+		if strings.HasSuffix(filename, ext) {
+			return true
+		}
+	}
+	return false
+}
+
+// unpackDeb extracts the data.tar member of a Debian package, an ar archive
+func unpackDeb(src *io.SectionReader, x *extractor) error {
+	x.rooted = true
+	r := bufio.NewReader(src)
+
+	magic := make([]byte, 8)
+	if _, err := io.ReadFull(r, magic); err != nil || string(magic) != "!<arch>\n" {
+		return fmt.Errorf("%w: not a deb package", ErrInvalidAsset)
+	}
+
+	header := make([]byte, 60)
+	for {
+		if _, err := io.ReadFull(r, header); err != nil {
+			if errors.Is(err, io.EOF) {
+				return fmt.Errorf("%w: no data.tar in deb package", ErrInvalidAsset)
+			}
+			return err
+		}
+		name := strings.TrimSuffix(strings.TrimSpace(string(header[0:16])), "/")
+		size, err := strconv.ParseInt(strings.TrimSpace(string(header[48:58])), 10, 64)
+		if err != nil || size < 0 {
+			return fmt.Errorf("%w: invalid ar member size of %s", ErrInvalidAsset, name)
+		}
+
+		if ext, ok := strings.CutPrefix(name, "data.tar"); ok {
+			member := io.LimitReader(r, size)
+			if ext == "" {
+				return unpackTar(member, x)
+			}
+			decompress, ok := decompressors[ext]
+			if !ok {
+				return fmt.Errorf("%w: unsupported deb payload %s", ErrInvalidAsset, name)
+			}
+			dr, err := decompress(member)
+			if err != nil {
+				return err
+			}
+			defer dr.Close()
+			return unpackTar(dr, x)
+		}
+
+		// Members are padded to an even size
+		if _, err := r.Discard(int(size + size%2)); err != nil {
+			return err
+		}
+	}
+}
+
+// unpackRpm extracts the cpio payload of an RPM package
+func unpackRpm(src *io.SectionReader, x *extractor) error {
+	x.rooted = true
+	r := bufio.NewReader(src)
+
+	lead := make([]byte, 96)
+	if _, err := io.ReadFull(r, lead); err != nil || !bytes.HasPrefix(lead, []byte{0xed, 0xab, 0xee, 0xdb}) {
+		return fmt.Errorf("%w: not an rpm package", ErrInvalidAsset)
+	}
+
+	// The signature header is padded to 8 bytes, the main header isn't
+	sigSize, err := skipRpmHeader(r)
+	if err != nil {
+		return err
+	}
+	if _, err := r.Discard(int((8 - sigSize%8) % 8)); err != nil {
+		return err
+	}
+	if _, err := skipRpmHeader(r); err != nil {
+		return err
+	}
+
+	payload := io.Reader(r)
+	head, _ := r.Peek(8)
+	for _, m := range archiveMagics {
+		if decompress, ok := decompressors[m.ext]; ok && bytes.HasPrefix(head, m.magic) {
+			dr, err := decompress(r)
+			if err != nil {
+				return err
+			}
+			defer dr.Close()
+			payload = dr
+			break
+		}
+	}
+	return unpackCpio(payload, x)
+}
+
+// skipRpmHeader skips an RPM header structure and returns its size
+func skipRpmHeader(r *bufio.Reader) (int64, error) {
+	intro := make([]byte, 16)
+	if _, err := io.ReadFull(r, intro); err != nil {
+		return 0, err
+	}
+	if !bytes.HasPrefix(intro, []byte{0x8e, 0xad, 0xe8}) {
+		return 0, fmt.Errorf("%w: invalid rpm header", ErrInvalidAsset)
+	}
+	entries := int64(binary.BigEndian.Uint32(intro[8:12]))
+	dataSize := int64(binary.BigEndian.Uint32(intro[12:16]))
+	size := 16 + entries*16 + dataSize
+	if _, err := r.Discard(int(size - 16)); err != nil {
+		return 0, err
+	}
+	return size, nil
+}
+
+// unpackCpio extracts the regular files, directories and symlinks of a cpio
+// archive in the "newc" format used by RPM
+func unpackCpio(r io.Reader, x *extractor) error {
+	br := bufio.NewReader(r)
+	header := make([]byte, 110)
+	for {
+		if _, err := io.ReadFull(br, header); err != nil {
+			return err
+		}
+		if magic := string(header[0:6]); magic != "070701" && magic != "070702" {
+			return fmt.Errorf("%w: unsupported cpio format", ErrInvalidAsset)
+		}
+		// The fields are unsigned 32 bit hex numbers, ParseUint rejects signs
+		field := func(i int) (int64, error) {
+			n, err := strconv.ParseUint(string(header[6+i*8:14+i*8]), 16, 32)
+			return int64(n), err
+		}
+		mode, err1 := field(1)
+		size, err2 := field(6)
+		nameSize, err3 := field(11)
+		if err := errors.Join(err1, err2, err3); err != nil {
+			return fmt.Errorf("%w: invalid cpio header: %w", ErrInvalidAsset, err)
+		}
+		if nameSize == 0 {
+			return fmt.Errorf("%w: cpio entry without name", ErrInvalidAsset)
+		}
+		if nameSize > maxLinkTarget {
+			return fmt.Errorf("%w: cpio entry name too long", ErrInvalidAsset)
+		}
+
+		// The name and the data are padded to 4 bytes
+		name := make([]byte, nameSize+(4-(110+nameSize)%4)%4)
+		if _, err := io.ReadFull(br, name); err != nil {
+			return err
+		}
+		entry := strings.TrimRight(string(name[:nameSize]), "\x00")
+		if entry == "TRAILER!!!" {
+			return nil
+		}
+		data := io.LimitReader(br, size)
+		padding := (4 - size%4) % 4
+
+		if err := extractCpioEntry(data, x, entry, mode); err != nil {
+			return err
+		}
+		if _, err := io.Copy(io.Discard, data); err != nil {
+			return err
+		}
+		if _, err := br.Discard(int(padding)); err != nil {
+			return err
+		}
+	}
+}
+
+// extractCpioEntry extracts a cpio entry with the unix mode with x.
+// Entries other than regular files, directories and symlinks are skipped.
+func extractCpioEntry(data io.Reader, x *extractor, name string, mode int64) error {
+	const typeMask, typeDir, typeReg, typeLnk = 0o170000, 0o040000, 0o100000, 0o120000
+
+	name = strings.TrimPrefix(name, "./")
+	if name == "" || name == "." {
+		return nil
+	}
+
+	switch mode & typeMask {
+	case typeDir:
+		return x.mkdir(name)
+	case typeReg:
+		return x.writeFile(name, data, os.FileMode(mode).Perm())
+	case typeLnk:
+		target, err := readLinkTarget(name, data)
+		if err != nil {
+			return err
+		}
+		return x.symlink(name, target)
+	}
+	return nil
+}